@@ -0,0 +1,107 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Anchor IDs of the documented elements.
+//
+// The anchor of an element is its kind, a dash, and its fully qualified proto
+// name, like "message-myorg.billing.Invoice" or "field-myorg.billing.Invoice.total".
+// Proto names are case sensitive and only contain letters, digits, underscores
+// and dots, and kinds never contain dashes, so anchors are unique and don't change
+// between releases. File paths may also contain "/" and "-", any other character
+// is escaped as "~" and its hex code.
+//
+// Anchors are case sensitive, but Sphinx lowercases the labels, so in the
+// reStructuredText documentation anchors that only differ in case, like of the
+// messages "Id" and "ID", collide.
+//
+// Kinds:
+//   package   package name
+//   service   service
+//   rpc       service.method
+//   enum      enum
+//   enumvalue enum.constant
+//   message   message
+//   field     message.field
+//   oneof     message.oneof
+//   section   section name, optionally followed by "." and a package name
+//   source    proto file path, followed by ":L" and the line number for lines
+//   wellknown well-known type, on the appendix
+//   scalar    scalar value type, on the appendix
+
+// Kind of an anchor
+type AnchorKind string
+
+const (
+	AK_PACKAGE    AnchorKind = "package"
+	AK_SERVICE    AnchorKind = "service"
+	AK_RPC        AnchorKind = "rpc"
+	AK_ENUM       AnchorKind = "enum"
+	AK_ENUM_VALUE AnchorKind = "enumvalue"
+	AK_MESSAGE    AnchorKind = "message"
+	AK_FIELD      AnchorKind = "field"
+	AK_ONEOF      AnchorKind = "oneof"
+	AK_SECTION    AnchorKind = "section"
+	AK_SOURCE     AnchorKind = "source"
+	AK_WELL_KNOWN AnchorKind = "wellknown"
+	AK_SCALAR     AnchorKind = "scalar"
+)
+
+// Get the anchor ID of an element by its fully qualified name
+func Anchor(kind AnchorKind, fullName string) string {
+	var ret strings.Builder
+	ret.WriteString(string(kind))
+	ret.WriteByte('-')
+	for i := 0; i < len(fullName); i++ {
+		c := fullName[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '.' || c == '/' || c == '-' {
+			ret.WriteByte(c)
+		} else {
+			fmt.Fprintf(&ret, "~%02X", c)
+		}
+	}
+	return ret.String()
+}
+
+// Get the anchor ID of a member of an element, like a field of a message
+func MemberAnchor(kind AnchorKind, parent *fdep.DepType, name string) string {
+	return Anchor(kind, parent.FullOriginalName()+"."+name)
+}
+
+// Get the anchor ID of a service, enum or message
+func TypeAnchor(dt *fdep.DepType) string {
+	return Anchor(TypeAnchorKind(dt), dt.FullOriginalName())
+}
+
+// Get the anchor kind of a service, enum or message
+func TypeAnchorKind(dt *fdep.DepType) AnchorKind {
+	switch dt.Item.(type) {
+	case *fproto.ServiceElement:
+		return AK_SERVICE
+	case *fproto.EnumElement:
+		return AK_ENUM
+	}
+	return AK_MESSAGE
+}
+
+// Get the anchor ID of a page section, optionally of a package inside it
+func SectionAnchor(section string, pkg string) string {
+	if pkg == "" {
+		return Anchor(AK_SECTION, section)
+	}
+	return Anchor(AK_SECTION, section+"."+pkg)
+}
+
+// Get the anchor ID of a proto source file, or of a line of it if line > 0
+func SourceAnchor(filePath string, line int) string {
+	if line <= 0 {
+		return Anchor(AK_SOURCE, filePath)
+	}
+	return fmt.Sprintf("%s:L%d", Anchor(AK_SOURCE, filePath), line)
+}
@@ -0,0 +1,102 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Precedence of leading and trailing comments
+type CommentPrecedence int
+
+const (
+	CP_LEADING_TRAILING CommentPrecedence = iota // leading comment followed by trailing comment
+	CP_TRAILING_LEADING                          // trailing comment followed by leading comment
+	CP_LEADING                                   // leading comment, trailing only if there is no leading
+	CP_TRAILING                                  // trailing comment, leading only if there is no trailing
+)
+
+// Parse the comment precedence name
+func ParseCommentPrecedence(name string) (CommentPrecedence, error) {
+	switch name {
+	case "", "leading_trailing":
+		return CP_LEADING_TRAILING, nil
+	case "trailing_leading":
+		return CP_TRAILING_LEADING, nil
+	case "leading":
+		return CP_LEADING, nil
+	case "trailing":
+		return CP_TRAILING, nil
+	}
+	return CP_LEADING_TRAILING, fmt.Errorf("Unknown comment precedence: %s", name)
+}
+
+// Merge leading and trailing comments using the precedence
+func MergeComments(leading *fproto.Comment, trailing *fproto.Comment, precedence CommentPrecedence) *fproto.Comment {
+	if isEmptyComment(trailing) {
+		return leading
+	}
+	if isEmptyComment(leading) {
+		return trailing
+	}
+
+	switch precedence {
+	case CP_LEADING:
+		return leading
+	case CP_TRAILING:
+		return trailing
+	case CP_TRAILING_LEADING:
+		return &fproto.Comment{Lines: append(append(append([]string{}, trailing.Lines...), ""), leading.Lines...)}
+	default:
+		return &fproto.Comment{Lines: append(append(append([]string{}, leading.Lines...), ""), trailing.Lines...)}
+	}
+}
+
+func isEmptyComment(comment *fproto.Comment) bool {
+	if comment == nil {
+		return true
+	}
+	for _, l := range comment.Lines {
+		if l != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the trailing comment of a declaration inside a type (field, enum constant or RPC).
+// Returns nil if no source loader was set or if the source has no trailing comment.
+func (g *Helper) TrailingComment(dt *fdep.DepType, name string) *fproto.Comment {
+	if dt.DepFile == nil {
+		return nil
+	}
+
+	decl := g.source.GetFile(dt.DepFile.FilePath).FindDecl(dt.Name + "." + name)
+	if decl == nil || decl.TrailingComment == nil {
+		return nil
+	}
+	return &fproto.Comment{Lines: decl.TrailingComment}
+}
+
+// Get the comment of a declaration inside a type, merging the leading and the trailing comments
+func (g *Helper) ItemComment(dt *fdep.DepType, name string, leading *fproto.Comment, precedence CommentPrecedence) *fproto.Comment {
+	return MergeComments(leading, g.TrailingComment(dt, name), precedence)
+}
+
+// Get the comment as plain text, removing empty lines at the start and at the end
+func CommentText(comment *fproto.Comment) string {
+	if comment == nil {
+		return ""
+	}
+
+	start, end := 0, len(comment.Lines)
+	for start < end && strings.TrimSpace(comment.Lines[start]) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(comment.Lines[end-1]) == "" {
+		end--
+	}
+	return strings.Join(comment.Lines[start:end], "\n")
+}
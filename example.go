@@ -0,0 +1,198 @@
+package fproto_doc
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Maximum nesting of messages in the examples, deeper messages are shown empty
+const ExampleMaxDepth = 4
+
+// JSON object of an example, keeping the order of the properties
+type ExampleObject []*ExampleProperty
+
+type ExampleProperty struct {
+	Name  string
+	Value interface{}
+}
+
+func (o ExampleObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for pidx, p := range o {
+		if pidx > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Builds an example JSON value of a message, following the proto3 JSON mapping.
+// Repeated fields and maps have one item, enums use their first value, only the
+// first field of oneofs is set, and recursive messages are shown empty.
+func (g *Helper) ExampleJSON(dt *fdep.DepType, rules *ExcludeRules, order FieldOrder) (interface{}, error) {
+	b := &exampleBuilder{helper: g, rules: rules, order: order, building: make(map[string]bool)}
+	return b.typeValue(dt)
+}
+
+// Example JSON of a message, indented
+func (g *Helper) ExampleJSONText(dt *fdep.DepType, rules *ExcludeRules, order FieldOrder) (string, error) {
+	value, err := g.ExampleJSON(dt, rules, order)
+	if err != nil {
+		return "", err
+	}
+	ret, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(ret), nil
+}
+
+type exampleBuilder struct {
+	helper   *Helper
+	rules    *ExcludeRules
+	order    FieldOrder
+	building map[string]bool // messages being built, to stop recursion
+	depth    int
+}
+
+func (b *exampleBuilder) typeValue(dt *fdep.DepType) (interface{}, error) {
+	if jt, ok := WellKnownJSONType(dt.FullOriginalName()); ok {
+		if dt.FullOriginalName() == "google.protobuf.Any" {
+			return ExampleObject{{Name: "@type", Value: "type.googleapis.com/google.protobuf.Empty"}}, nil
+		}
+		return exampleJSONValue(jt), nil
+	}
+
+	switch item := dt.Item.(type) {
+	case *fproto.EnumElement:
+		constants := b.helper.FilterEnumConstantList(dt, item.EnumConstants, b.rules)
+		if len(constants) == 0 {
+			return 0, nil
+		}
+		return constants[0].Name, nil
+	case *fproto.MessageElement:
+		name := dt.FullOriginalName()
+		if b.building[name] || b.depth >= ExampleMaxDepth {
+			return ExampleObject{}, nil
+		}
+
+		b.building[name] = true
+		b.depth++
+		defer func() {
+			delete(b.building, name)
+			b.depth--
+		}()
+
+		ret := ExampleObject{}
+		if err := b.addFields(&ret, dt, item.Fields); err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+	return nil, nil
+}
+
+func (b *exampleBuilder) addFields(obj *ExampleObject, dt *fdep.DepType, fields []fproto.FieldElementTag) error {
+	for _, fld := range b.helper.OrderedFieldList(b.helper.FilterFieldList(dt, fields, b.rules), b.order) {
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			value, err := b.fieldValue(dt, xfld.Type)
+			if err != nil {
+				return err
+			}
+			if xfld.Repeated {
+				value = []interface{}{value}
+			}
+			*obj = append(*obj, &ExampleProperty{Name: FieldJSONName(xfld), Value: value})
+		case *fproto.MapFieldElement:
+			key, err := b.fieldValue(dt, xfld.KeyType)
+			if err != nil {
+				return err
+			}
+			value, err := b.fieldValue(dt, xfld.Type)
+			if err != nil {
+				return err
+			}
+			// map keys are always strings
+			key_text, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			key_name := string(key_text)
+			if s, ok := key.(string); ok {
+				key_name = s
+			}
+			*obj = append(*obj, &ExampleProperty{Name: FieldJSONName(xfld.FieldElement), Value: ExampleObject{{Name: key_name, Value: value}}})
+		case *fproto.OneOfFieldElement:
+			// only one of the fields may be set
+			oof_fields := b.helper.FilterFieldList(dt, xfld.Fields, b.rules)
+			if len(oof_fields) > 0 {
+				if err := b.addFields(obj, dt, oof_fields[:1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (b *exampleBuilder) fieldValue(parent *fdep.DepType, typeName string) (interface{}, error) {
+	if jt, ok := ScalarJSONType(typeName); ok {
+		return exampleJSONValue(jt), nil
+	}
+
+	ft, err := parent.FindType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if ft == nil {
+		return nil, nil
+	}
+	return b.typeValue(ft)
+}
+
+// Example value of a JSON mapping type
+func exampleJSONValue(jt JSONType) interface{} {
+	switch jt.Format {
+	case "int64", "uint64":
+		return "0"
+	case "byte":
+		return ""
+	case "date-time":
+		return "1970-01-01T00:00:00Z"
+	case "duration":
+		return "0s"
+	case "field-mask":
+		return ""
+	}
+
+	switch jt.Type {
+	case "number", "integer":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		return ""
+	case "object":
+		return ExampleObject{}
+	case "array":
+		return []interface{}{}
+	}
+	return nil
+}
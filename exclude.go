@@ -0,0 +1,175 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Rules to exclude elements from the documentation
+type ExcludeRules struct {
+	CommentMarkers []string         // comment markers, like "@internal"
+	Options        []string         // options that exclude the element when set to true, like "(myorg.internal)"
+	NamePatterns   []*regexp.Regexp // patterns matched against the full name of the element
+}
+
+func NewExcludeRules() *ExcludeRules {
+	return &ExcludeRules{}
+}
+
+func (er *ExcludeRules) AddCommentMarker(marker string) *ExcludeRules {
+	er.CommentMarkers = append(er.CommentMarkers, marker)
+	return er
+}
+
+func (er *ExcludeRules) AddOption(option string) *ExcludeRules {
+	er.Options = append(er.Options, option)
+	return er
+}
+
+func (er *ExcludeRules) AddNamePattern(pattern string) (*ExcludeRules, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return er, fmt.Errorf("Invalid name pattern '%s': %v", pattern, err)
+	}
+	er.NamePatterns = append(er.NamePatterns, re)
+	return er, nil
+}
+
+// Returns whether there are any rules
+func (er *ExcludeRules) IsEmpty() bool {
+	return er == nil || (len(er.CommentMarkers) == 0 && len(er.Options) == 0 && len(er.NamePatterns) == 0)
+}
+
+// Checks if an element must be excluded
+func (er *ExcludeRules) IsExcluded(fullName string, comment *fproto.Comment, options []*fproto.OptionElement) bool {
+	if er.IsEmpty() {
+		return false
+	}
+
+	for _, re := range er.NamePatterns {
+		if re.MatchString(fullName) {
+			return true
+		}
+	}
+
+	if comment != nil {
+		for _, marker := range er.CommentMarkers {
+			for _, cl := range comment.Lines {
+				if strings.Contains(cl, marker) {
+					return true
+				}
+			}
+		}
+	}
+
+	for _, opt := range er.Options {
+		if o := FindOption(options, opt); o != nil && OptionValue(o) == "true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks if a type must be excluded, also when a parent message is excluded
+func (g *Helper) IsExcludedType(dt *fdep.DepType, rules *ExcludeRules) bool {
+	if rules.IsEmpty() {
+		return false
+	}
+	return rules.IsExcludedElement(dt.FullOriginalName(), dt.Item)
+}
+
+// Checks if a message, enum or service must be excluded, or any of the messages
+// it is nested in. The names of the parents are taken from the full name.
+func (er *ExcludeRules) IsExcludedElement(fullName string, item fproto.FProtoElement) bool {
+	if er.IsEmpty() {
+		return false
+	}
+
+	name := fullName
+	for item != nil {
+		var comment *fproto.Comment
+		var options []*fproto.OptionElement
+		switch e := item.(type) {
+		case *fproto.MessageElement:
+			comment, options = e.Comment, e.Options
+		case *fproto.EnumElement:
+			comment, options = e.Comment, e.Options
+		case *fproto.ServiceElement:
+			comment, options = e.Comment, e.Options
+		default:
+			// the file
+			return false
+		}
+
+		if er.IsExcluded(name, comment, options) {
+			return true
+		}
+
+		if pos := strings.LastIndex(name, "."); pos >= 0 {
+			name = name[:pos]
+		}
+		item = item.ParentElement()
+	}
+	return false
+}
+
+// Get the list of fields that are not excluded
+func (g *Helper) FilterFieldList(dt *fdep.DepType, fields []fproto.FieldElementTag, rules *ExcludeRules) []fproto.FieldElementTag {
+	if rules.IsEmpty() {
+		return fields
+	}
+
+	var ret []fproto.FieldElementTag
+	for _, fld := range fields {
+		var comment *fproto.Comment
+		var options []*fproto.OptionElement
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			comment, options = xfld.Comment, xfld.Options
+		case *fproto.MapFieldElement:
+			comment, options = xfld.Comment, xfld.Options
+		case *fproto.OneOfFieldElement:
+			comment, options = xfld.Comment, xfld.Options
+		}
+
+		if !rules.IsExcluded(dt.FullOriginalName()+"."+fld.FieldName(), g.ItemComment(dt, fld.FieldName(), comment, CP_LEADING_TRAILING), options) {
+			ret = append(ret, fld)
+		}
+	}
+	return ret
+}
+
+// Get the list of enum constants that are not excluded
+func (g *Helper) FilterEnumConstantList(dt *fdep.DepType, constants []*fproto.EnumConstantElement, rules *ExcludeRules) []*fproto.EnumConstantElement {
+	if rules.IsEmpty() {
+		return constants
+	}
+
+	var ret []*fproto.EnumConstantElement
+	for _, ec := range constants {
+		if !rules.IsExcluded(dt.FullOriginalName()+"."+ec.Name, g.ItemComment(dt, ec.Name, ec.Comment, CP_LEADING_TRAILING), ec.Options) {
+			ret = append(ret, ec)
+		}
+	}
+	return ret
+}
+
+// Get the list of RPCs that are not excluded
+func (g *Helper) FilterRPCList(dt *fdep.DepType, rpcs []*fproto.RPCElement, rules *ExcludeRules) []*fproto.RPCElement {
+	if rules.IsEmpty() {
+		return rpcs
+	}
+
+	var ret []*fproto.RPCElement
+	for _, rpc := range rpcs {
+		if !rules.IsExcluded(dt.FullOriginalName()+"."+rpc.Name, g.ItemComment(dt, rpc.Name, rpc.Comment, CP_LEADING_TRAILING), rpc.Options) {
+			ret = append(ret, rpc)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,42 @@
+package fproto_doc
+
+import (
+	"testing"
+
+	"github.com/RangelReale/fproto"
+)
+
+func TestIsExcludedElement(t *testing.T) {
+	file := &fproto.ProtoFile{PackageName: "myorg"}
+	internal := &fproto.MessageElement{Parent: file, Name: "Internal", Comment: &fproto.Comment{Lines: []string{"Not public @internal"}}}
+	nested := &fproto.MessageElement{Parent: internal, Name: "Nested"}
+	nestedEnum := &fproto.EnumElement{Parent: nested, Name: "Kind"}
+	public := &fproto.MessageElement{Parent: file, Name: "Public"}
+	publicEnum := &fproto.EnumElement{Parent: public, Name: "Kind"}
+	hidden := &fproto.MessageElement{Parent: file, Name: "Hidden"}
+	hiddenNested := &fproto.MessageElement{Parent: hidden, Name: "Nested"}
+
+	rules := NewExcludeRules().AddCommentMarker("@internal")
+	if _, err := rules.AddNamePattern(`^myorg\.Hidden$`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fullName string
+		item     fproto.FProtoElement
+		want     bool
+	}{
+		{"myorg.Internal", internal, true},
+		{"myorg.Internal.Nested", nested, true},
+		{"myorg.Internal.Nested.Kind", nestedEnum, true},
+		{"myorg.Public", public, false},
+		{"myorg.Public.Kind", publicEnum, false},
+		{"myorg.Hidden.Nested", hiddenNested, true},
+	}
+
+	for _, tt := range tests {
+		if got := rules.IsExcludedElement(tt.fullName, tt.item); got != tt.want {
+			t.Errorf("IsExcludedElement(%q) = %v, want %v", tt.fullName, got, tt.want)
+		}
+	}
+}
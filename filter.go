@@ -0,0 +1,227 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Checks if the element kind was selected by the filter
+func (gf *GetFilter) IsKindSelected(kind ElementKind) bool {
+	return gf.Kinds == 0 || gf.Kinds&kind != 0
+}
+
+func (gf *GetFilter) matchFile(filePath string) bool {
+	if len(gf.FileGlobs) == 0 {
+		return true
+	}
+	for _, fg := range gf.FileGlobs {
+		if fg.MatchString(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gf *GetFilter) matchPackage(pkg string) bool {
+	for _, p := range gf.ExcludePackages {
+		if MatchPackage(p, pkg) {
+			return false
+		}
+	}
+
+	if len(gf.Packages) == 0 {
+		return true
+	}
+	for _, p := range gf.Packages {
+		if MatchPackage(p, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gf *GetFilter) matchName(fullName string) bool {
+	if len(gf.NamePatterns) == 0 {
+		return true
+	}
+	for _, re := range gf.NamePatterns {
+		if re.MatchString(fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the package matches the pattern.
+// A pattern ending in ".*" matches the package and all its subpackages.
+func MatchPackage(pattern string, pkg string) bool {
+	if strings.HasSuffix(pattern, ".*") {
+		base := strings.TrimSuffix(pattern, ".*")
+		return pkg == base || strings.HasPrefix(pkg, base+".")
+	}
+	return pattern == pkg
+}
+
+// Checks if the file path matches the glob.
+// "*" matches any sequence of characters except "/", "**/" matches zero or more
+// directories, "**" elsewhere matches any sequence of characters, and "?" matches
+// one character except "/".
+func MatchFileGlob(glob string, filePath string) (bool, error) {
+	re, err := CompileFileGlobs([]string{glob})
+	if err != nil {
+		return false, err
+	}
+	return re[0].MatchString(filePath), nil
+}
+
+// Compile a list of file path globs
+func CompileFileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	var ret []*regexp.Regexp
+	for _, g := range globs {
+		re, err := regexp.Compile(globToRegexp(g))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid file glob '%s': %v", g, err)
+		}
+		ret = append(ret, re)
+	}
+	return ret, nil
+}
+
+func globToRegexp(glob string) string {
+	var ret strings.Builder
+	ret.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				ret.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				ret.WriteString(".*")
+				i++
+			default:
+				ret.WriteString("[^/]*")
+			}
+		case '?':
+			ret.WriteString("[^/]")
+		default:
+			ret.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	ret.WriteString("$")
+	return ret.String()
+}
+
+// Compile a list of regular expressions
+func CompileNamePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var ret []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid name pattern '%s': %v", p, err)
+		}
+		ret = append(ret, re)
+	}
+	return ret, nil
+}
+
+// Parse a list of element kind names (service, enum, message)
+func ParseElementKinds(names []string) (ElementKind, error) {
+	var ret ElementKind
+	for _, n := range names {
+		for _, kn := range strings.Split(n, ",") {
+			switch strings.TrimSpace(kn) {
+			case "service":
+				ret |= EK_SERVICE
+			case "enum":
+				ret |= EK_ENUM
+			case "message":
+				ret |= EK_MESSAGE
+			case "":
+			default:
+				return 0, fmt.Errorf("Unknown element kind: %s", kn)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Parse a sort type name (none, filepath_alias_name, alias_name, name)
+func ParseSortType(name string) (SortType, error) {
+	switch name {
+	case "none":
+		return ST_NONE, nil
+	case "filepath_alias_name":
+		return ST_FILEPATH_ALIAS_NAME, nil
+	case "", "alias_name":
+		return ST_ALIAS_NAME, nil
+	case "name":
+		return ST_NAME, nil
+	}
+	return ST_ALIAS_NAME, fmt.Errorf("Unknown sort type: %s", name)
+}
+
+// Parse a dependency type name (all, own, imported)
+func ParseFilterDepType(name string) (FilterDepType, error) {
+	switch name {
+	case "all":
+		return DT_ALL, nil
+	case "", "own":
+		return DT_OWN, nil
+	case "imported":
+		return DT_IMPORTED, nil
+	}
+	return DT_OWN, fmt.Errorf("Unknown dependency type: %s", name)
+}
+
+// Checks if the type is selected by the filter
+func (g *Helper) IsIncludedType(dt *fdep.DepType, filter *GetFilter) bool {
+	if dt.DepFile == nil {
+		return false
+	}
+
+	switch filter.FilterDepType {
+	case DT_OWN:
+		if dt.DepFile.DepType != fdep.DepType_Own {
+			return false
+		}
+	case DT_IMPORTED:
+		if dt.DepFile.DepType != fdep.DepType_Imported {
+			return false
+		}
+	}
+
+	if len(filter.FilePaths) > 0 {
+		found := false
+		for _, fp := range filter.FilePaths {
+			if fp == dt.DepFile.FilePath {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	var kind ElementKind
+	switch dt.Item.(type) {
+	case *fproto.ServiceElement:
+		kind = EK_SERVICE
+	case *fproto.EnumElement:
+		kind = EK_ENUM
+	case *fproto.MessageElement:
+		kind = EK_MESSAGE
+	}
+
+	return filter.IsKindSelected(kind) &&
+		filter.matchFile(dt.DepFile.FilePath) &&
+		filter.matchPackage(dt.DepFile.ProtoFile.PackageName) &&
+		filter.matchName(dt.FullOriginalName()) &&
+		!g.IsExcludedType(dt, filter.Exclude)
+}
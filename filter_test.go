@@ -0,0 +1,70 @@
+package fproto_doc
+
+import (
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"*.proto", `^[^/]*\.proto$`},
+		{"api/**", `^api/.*$`},
+		{"api/**/*.proto", `^api/(?:.*/)?[^/]*\.proto$`},
+		{"**/*.proto", `^(?:.*/)?[^/]*\.proto$`},
+		{"**.proto", `^.*\.proto$`},
+		{"v?/file.proto", `^v[^/]/file\.proto$`},
+		{"a+b(c)[d]{e}^$|.proto", `^a\+b\(c\)\[d\]\{e\}\^\$\|\.proto$`},
+	}
+
+	for _, tt := range tests {
+		if got := globToRegexp(tt.glob); got != tt.want {
+			t.Errorf("globToRegexp(%q) = %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFileGlob(t *testing.T) {
+	tests := []struct {
+		glob     string
+		filePath string
+		want     bool
+	}{
+		{"*.proto", "file.proto", true},
+		{"*.proto", "api/file.proto", false},
+		{"*.proto", "file.protox", false},
+		{"api/*.proto", "api/file.proto", true},
+		{"api/*.proto", "api/v1/file.proto", false},
+		{"api/**", "api/v1/file.proto", true},
+		{"api/**", "other/api/file.proto", false},
+		{"api/**/*.proto", "api/v1/v2/file.proto", true},
+		{"api/**/*.proto", "api/file.proto", true},
+		{"api/**/*.proto", "apix/file.proto", false},
+		{"**/*.proto", "file.proto", true},
+		{"**/*.proto", "api/v1/file.proto", true},
+		{"api/**/v1/*.proto", "api/v1/file.proto", true},
+		{"api/**/v1/*.proto", "api/x/v1/file.proto", true},
+		{"api/**/v1/*.proto", "api/xv1/file.proto", false},
+		{"**.proto", "api/v1/file.proto", true},
+		{"v?/file.proto", "v1/file.proto", true},
+		{"v?/file.proto", "v10/file.proto", false},
+		{"v?/file.proto", "v//file.proto", false},
+		{"file.proto", "fileXproto", false},
+		{"a+b.proto", "a+b.proto", true},
+		{"a+b.proto", "aab.proto", false},
+		{"[ab].proto", "[ab].proto", true},
+		{"[ab].proto", "a.proto", false},
+		{"(x|y).proto", "x.proto", false},
+		{"^$.proto", "^$.proto", true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchFileGlob(tt.glob, tt.filePath)
+		if err != nil {
+			t.Errorf("MatchFileGlob(%q): %v", tt.glob, err)
+		} else if got != tt.want {
+			t.Errorf("MatchFileGlob(%q, %q) = %v, want %v", tt.glob, tt.filePath, got, tt.want)
+		}
+	}
+}
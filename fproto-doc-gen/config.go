@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/RangelReale/fproto-doc"
+	"gopkg.in/yaml.v2"
+)
+
+// Configuration file
+type Config struct {
+	IncPaths          []string                  `yaml:"inc_paths"`
+	ProtoPaths        []*ProtoPath              `yaml:"proto_paths"`
+	CommentPrecedence string                    `yaml:"comment_precedence"`
+	Links             []*fproto_doc.LinkMapping `yaml:"links"`
+
+	// source repository link template, with {commit}, {path} and {line} placeholders.
+	// The path is relative to the proto path directory, without the root.
+	SourceLink string `yaml:"source_link"`
+	// source commit, if blank it is read from the git repository of the proto paths
+	Commit string `yaml:"commit"`
+
+	// external generator executables
+	Plugins []*PluginConfig `yaml:"plugins"`
+
+	// output path of the internal documentation, generated from the default profile without the exclusion rules
+	InternalOutputPath string `yaml:"internal_output_path"`
+
+	// default profile, used when no profiles are set
+	Profile `yaml:",inline"`
+
+	// profiles inherit the values of the default profile that they don't set
+	Profiles []*Profile `yaml:"profiles"`
+}
+
+// External generator executable
+type PluginConfig struct {
+	Name    string   `yaml:"name"` // format name, if blank the name from the plugin description is used
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+}
+
+// Parse the command line plugin format "name=command" or "command"
+func ParsePlugin(plugin string) *PluginConfig {
+	ret := &PluginConfig{Command: plugin}
+	if pos := strings.Index(plugin, "="); pos >= 0 {
+		ret.Name, ret.Command = plugin[:pos], plugin[pos+1:]
+	}
+	return ret
+}
+
+// Application proto files root path
+type ProtoPath struct {
+	Dir  string `yaml:"dir"`
+	Root string `yaml:"root"` // path prefix of the files
+}
+
+// Parse the command line proto path format "dir;root"
+func ParseProtoPath(pp string) *ProtoPath {
+	parse_root := strings.Split(pp, ";")
+
+	ret := &ProtoPath{Dir: parse_root[0]}
+	if len(parse_root) > 1 {
+		ret.Root = parse_root[1]
+	}
+	return ret
+}
+
+// Documentation profile, each profile generates one documentation set
+type Profile struct {
+	Name       string `yaml:"name"`
+	Title      string `yaml:"title"`
+	Theme      string `yaml:"theme"`       // CSS file appended to the default style
+	FieldOrder string `yaml:"field_order"` // order of the message fields, enum constants and RPCs, RPCs ignore the tag order
+	OutputPath string `yaml:"output_path"`
+
+	// output formats, as "name" or "name=file", the file is relative to the output path
+	Formats []string `yaml:"formats"`
+
+	// generator specific options, by format name
+	FormatOptions map[string]map[string]string `yaml:"format_options"`
+
+	IncludeFiles    []string `yaml:"include_files"`
+	FileGlobs       []string `yaml:"file_globs"` // matched against the file paths with the proto path root
+	Packages        []string `yaml:"packages"`
+	ExcludePackages []string `yaml:"exclude_packages"`
+	NameRegexes     []string `yaml:"name_regexes"`
+	Kinds           []string `yaml:"kinds"`
+
+	ExcludeMarkers []string `yaml:"exclude_markers"`
+	ExcludeOptions []string `yaml:"exclude_options"`
+	ExcludeNames   []string `yaml:"exclude_names"`
+}
+
+// Get a copy of the profile with the values it doesn't set taken from the parent profile.
+// The format options are merged by option name, an empty list clears the parent list.
+func (p *Profile) Inherit(parent *Profile) *Profile {
+	ret := *parent
+	ret.Name = p.Name
+	ret.OutputPath = p.OutputPath
+	if p.Title != "" {
+		ret.Title = p.Title
+	}
+	if p.Theme != "" {
+		ret.Theme = p.Theme
+	}
+	if p.FieldOrder != "" {
+		ret.FieldOrder = p.FieldOrder
+	}
+
+	ret.Formats = inheritList(p.Formats, parent.Formats)
+	ret.IncludeFiles = inheritList(p.IncludeFiles, parent.IncludeFiles)
+	ret.FileGlobs = inheritList(p.FileGlobs, parent.FileGlobs)
+	ret.Packages = inheritList(p.Packages, parent.Packages)
+	ret.ExcludePackages = inheritList(p.ExcludePackages, parent.ExcludePackages)
+	ret.NameRegexes = inheritList(p.NameRegexes, parent.NameRegexes)
+	ret.Kinds = inheritList(p.Kinds, parent.Kinds)
+	ret.ExcludeMarkers = inheritList(p.ExcludeMarkers, parent.ExcludeMarkers)
+	ret.ExcludeOptions = inheritList(p.ExcludeOptions, parent.ExcludeOptions)
+	ret.ExcludeNames = inheritList(p.ExcludeNames, parent.ExcludeNames)
+
+	ret.FormatOptions = make(map[string]map[string]string)
+	for _, fo := range []map[string]map[string]string{parent.FormatOptions, p.FormatOptions} {
+		for format, options := range fo {
+			if ret.FormatOptions[format] == nil {
+				ret.FormatOptions[format] = make(map[string]string)
+			}
+			for name, value := range options {
+				ret.FormatOptions[format][name] = value
+			}
+		}
+	}
+
+	return &ret
+}
+
+// Get the list if it was set, or the parent list
+func inheritList(list []string, parent []string) []string {
+	if list != nil {
+		return list
+	}
+	return parent
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Profile: Profile{
+			Name: "default",
+		},
+	}
+}
+
+// Load the configuration file
+func LoadConfig(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading config file '%s': %v", filename, err)
+	}
+
+	cfg := NewConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("Error parsing config file '%s': %v", filename, err)
+	}
+
+	// relative paths are relative to the config file directory
+	dir := filepath.Dir(filename)
+
+	for _, pp := range cfg.ProtoPaths {
+		if pp.Dir == "" {
+			return nil, fmt.Errorf("Config file '%s' has a proto path without a directory", filename)
+		}
+		pp.Dir = configPath(dir, pp.Dir)
+	}
+	for ipidx, ip := range cfg.IncPaths {
+		cfg.IncPaths[ipidx] = configPath(dir, ip)
+	}
+	for _, p := range cfg.Plugins {
+		// commands without a directory are searched on the PATH
+		if strings.ContainsAny(p.Command, `/\`) {
+			p.Command = configPath(dir, p.Command)
+		}
+	}
+	cfg.InternalOutputPath = configPath(dir, cfg.InternalOutputPath)
+	cfg.OutputPath = configPath(dir, cfg.OutputPath)
+	cfg.Theme = configPath(dir, cfg.Theme)
+
+	for pidx, p := range cfg.Profiles {
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("profile%d", pidx+1)
+		}
+		if p.OutputPath == "" {
+			return nil, fmt.Errorf("The output path of profile '%s' is required", p.Name)
+		}
+		p.OutputPath = configPath(dir, p.OutputPath)
+		p.Theme = configPath(dir, p.Theme)
+		cfg.Profiles[pidx] = p.Inherit(&cfg.Profile)
+	}
+
+	return cfg, nil
+}
+
+// Get a path of the config file relative to its directory
+func configPath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Directories containing proto files
+func (c *Config) WatchDirs() []string {
+	var ret []string
+	for _, pp := range c.ProtoPaths {
+		ret = append(ret, pp.Dir)
+	}
+	return append(ret, c.IncPaths...)
+}
+
+// Build the exclusion rules of the profile
+func (p *Profile) ExcludeRules() (*fproto_doc.ExcludeRules, error) {
+	exclude := fproto_doc.NewExcludeRules()
+	for _, em := range p.ExcludeMarkers {
+		exclude.AddCommentMarker(em)
+	}
+	for _, eo := range p.ExcludeOptions {
+		exclude.AddOption(eo)
+	}
+	for _, en := range p.ExcludeNames {
+		if _, err := exclude.AddNamePattern(en); err != nil {
+			return nil, fmt.Errorf("Error in profile '%s': %v", p.Name, err)
+		}
+	}
+	return exclude, nil
+}
+
+// Build the element filter of the profile
+func (p *Profile) Filter() (*fproto_doc.GetFilter, error) {
+	exclude, err := p.ExcludeRules()
+	if err != nil {
+		return nil, err
+	}
+
+	namePatterns, err := fproto_doc.CompileNamePatterns(p.NameRegexes)
+	if err != nil {
+		return nil, fmt.Errorf("Error in profile '%s': %v", p.Name, err)
+	}
+
+	fileGlobs, err := fproto_doc.CompileFileGlobs(p.FileGlobs)
+	if err != nil {
+		return nil, fmt.Errorf("Error in profile '%s': %v", p.Name, err)
+	}
+
+	kinds, err := fproto_doc.ParseElementKinds(p.Kinds)
+	if err != nil {
+		return nil, fmt.Errorf("Error in profile '%s': %v", p.Name, err)
+	}
+
+	return fproto_doc.NewGetFilter(fproto_doc.ST_ALIAS_NAME, fproto_doc.DT_OWN).
+		SetFilePaths(p.IncludeFiles).
+		SetFileGlobs(fileGlobs).
+		SetPackages(p.Packages, p.ExcludePackages).
+		SetNamePatterns(namePatterns).
+		SetKinds(kinds).
+		SetExclude(exclude), nil
+}
+
+// Output format
+type Format struct {
+	Name     string
+	FileName string
+}
+
+// Parse the output format "name" or "name=file"
+func ParseFormat(format string) (*Format, error) {
+	ret := &Format{Name: format}
+	if pos := strings.Index(format, "="); pos >= 0 {
+		ret.Name, ret.FileName = format[:pos], format[pos+1:]
+	}
+
+	info, err := getGenerator(ret.Name)
+	if err != nil {
+		return nil, err
+	}
+	if ret.FileName == "" {
+		ret.FileName = info.FileName
+	}
+	return ret, nil
+}
+
+// Output formats of the profile, HTML if none was set
+func (p *Profile) OutputFormats() ([]*Format, error) {
+	formats := p.Formats
+	if len(formats) == 0 {
+		formats = []string{"html"}
+	}
+
+	var ret []*Format
+	for _, f := range formats {
+		format, err := ParseFormat(f)
+		if err != nil {
+			return nil, fmt.Errorf("Error in profile '%s': %v", p.Name, err)
+		}
+		ret = append(ret, format)
+	}
+	return ret, nil
+}
+
+// Prefix of external generator executables found on the PATH
+const pluginPrefix = "fproto-doc-gen-"
+
+// Get a registered generator, or an external generator named "fproto-doc-gen-<name>" on the PATH
+func getGenerator(name string) (*fproto_doc.GeneratorInfo, error) {
+	if info, err := fproto_doc.GetGenerator(name); err == nil {
+		return info, nil
+	}
+
+	if command, err := exec.LookPath(pluginPrefix + name); err == nil {
+		if err := fproto_doc.RegisterExternalGenerator(name, command); err != nil {
+			return nil, err
+		}
+		return fproto_doc.GetGenerator(name)
+	}
+
+	return nil, fmt.Errorf("Unknown generator: %s (available: %s)", name, strings.Join(fproto_doc.GeneratorNames(), ", "))
+}
+
+// Register the external generators
+func (c *Config) RegisterPlugins() error {
+	for _, p := range c.Plugins {
+		if err := fproto_doc.RegisterExternalGenerator(p.Name, p.Command, p.Args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load the theme CSS
+func (p *Profile) StyleSheet() (string, error) {
+	if p.Theme == "" {
+		return "", nil
+	}
+
+	data, err := ioutil.ReadFile(p.Theme)
+	if err != nil {
+		return "", fmt.Errorf("Error reading theme of profile '%s': %v", p.Name, err)
+	}
+	return string(data), nil
+}
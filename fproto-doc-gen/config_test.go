@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfileInherit(t *testing.T) {
+	parent := &Profile{
+		Name:           "default",
+		Title:          "API",
+		Theme:          "theme.css",
+		FieldOrder:     "tag",
+		OutputPath:     "./doc",
+		Formats:        []string{"html", "openapi"},
+		Packages:       []string{"myorg.*"},
+		ExcludeMarkers: []string{"@internal"},
+		FormatOptions: map[string]map[string]string{
+			"html":    {"version": "v1", "source": "true"},
+			"openapi": {"server": "https://api.example.com"},
+		},
+	}
+
+	p := &Profile{
+		Name:           "partner",
+		Title:          "Partner API",
+		OutputPath:     "./doc/partner",
+		Packages:       []string{"myorg.billing.*"},
+		ExcludeMarkers: []string{}, // clears the parent list
+		FormatOptions: map[string]map[string]string{
+			"html":     {"version": "v2"},
+			"asciidoc": {"toc": "false"},
+		},
+	}
+
+	want := &Profile{
+		Name:           "partner",
+		Title:          "Partner API",
+		Theme:          "theme.css",
+		FieldOrder:     "tag",
+		OutputPath:     "./doc/partner",
+		Formats:        []string{"html", "openapi"},
+		Packages:       []string{"myorg.billing.*"},
+		ExcludeMarkers: []string{},
+		FormatOptions: map[string]map[string]string{
+			"html":     {"version": "v2", "source": "true"},
+			"openapi":  {"server": "https://api.example.com"},
+			"asciidoc": {"toc": "false"},
+		},
+	}
+
+	got := p.Inherit(parent)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// the parent format options must not be changed
+	if parent.FormatOptions["html"]["version"] != "v1" {
+		t.Errorf("the parent format options were changed: %v", parent.FormatOptions)
+	}
+}
+
+func TestLoadConfigPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fproto-doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "fproto-doc.yaml")
+	err = ioutil.WriteFile(filename, []byte(`
+inc_paths: [include, /usr/include]
+proto_paths:
+  - dir: ./proto
+theme: theme.css
+plugins:
+  - name: local
+    command: ./tools/plugin
+  - name: path
+    command: plugin
+profiles:
+  - name: public
+    output_path: doc
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"inc path", cfg.IncPaths[0], filepath.Join(dir, "include")},
+		{"absolute inc path", cfg.IncPaths[1], "/usr/include"},
+		{"proto path", cfg.ProtoPaths[0].Dir, filepath.Join(dir, "proto")},
+		{"plugin command", cfg.Plugins[0].Command, filepath.Join(dir, "tools/plugin")},
+		{"plugin command on the PATH", cfg.Plugins[1].Command, "plugin"},
+		{"profile output path", cfg.Profiles[0].OutputPath, filepath.Join(dir, "doc")},
+		{"inherited theme", cfg.Profiles[0].Theme, filepath.Join(dir, "theme.css")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -2,13 +2,21 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/RangelReale/fdep"
-	"github.com/RangelReale/fproto-doc/gen-html-default"
+	"github.com/RangelReale/fproto-doc"
+	_ "github.com/RangelReale/fproto-doc/gen-asciidoc"
+	_ "github.com/RangelReale/fproto-doc/gen-html-default"
+	_ "github.com/RangelReale/fproto-doc/gen-json"
+	_ "github.com/RangelReale/fproto-doc/gen-jsonschema"
+	_ "github.com/RangelReale/fproto-doc/gen-markdown"
+	_ "github.com/RangelReale/fproto-doc/gen-openapi"
+	_ "github.com/RangelReale/fproto-doc/gen-rst"
 )
 
 type arrayFlags []string
@@ -25,68 +33,236 @@ func (i *arrayFlags) Set(value string) error {
 var (
 	incPaths   = arrayFlags{}
 	protoPaths = arrayFlags{}
-	outputPath = flag.String("output_path", "", "Output root path")
+	outputPath = flag.String("output_path", "", "Output root path, can't be set when the config file has profiles")
+	configFile = flag.String("config", "", "Configuration file, command line flags override its values in all the profiles")
+	title      = flag.String("title", "", "Documentation title")
+	theme      = flag.String("theme", "", "CSS file appended to the default style")
+	fieldOrder = flag.String("field_order", "declaration", "Order of the message fields, enum constants and RPCs (declaration, name, tag), RPCs ignore the tag order")
+
+	sourceLink = flag.String("source_link", "", "Source repository link template, with {commit}, {path} (relative to the proto path directory) and {line} placeholders")
+	commit     = flag.String("commit", "", "Source commit of the source links, if blank it is read from the git repository of the proto paths")
+
+	commentPrecedence = flag.String("comment_precedence", "leading_trailing", "Precedence of leading and trailing comments (leading_trailing, trailing_leading, leading, trailing)")
+
+	excludeMarkers     = arrayFlags{}
+	excludeOptions     = arrayFlags{}
+	excludeNames       = arrayFlags{}
+	packages           = arrayFlags{}
+	excludePackages    = arrayFlags{}
+	fileGlobs          = arrayFlags{}
+	nameRegexes        = arrayFlags{}
+	kinds              = arrayFlags{}
+	formats            = arrayFlags{}
+	formatOptions      = arrayFlags{}
+	plugins            = arrayFlags{}
+	internalOutputPath = flag.String("internal_output_path", "", "Output path of the internal documentation, without the exclusion rules")
+
+	watchMode    = flag.Bool("watch", false, "Keep running, regenerating the documentation when the proto files change")
+	serveAddr    = flag.String("addr", "localhost:8080", "Address of the preview server (serve command)")
+	pollInterval = flag.Duration("poll_interval", time.Second, "Interval to check the proto files for changes (watch mode and serve command)")
 )
 
 func main() {
 	// parse flags
 	flag.Var(&incPaths, "inc_path", "Include paths (can be set multiple times)")
 	flag.Var(&protoPaths, "proto_path", "Application proto files root paths (can be set multiple times)")
-	flag.Parse()
+	flag.Var(&excludeMarkers, "exclude_marker", "Exclude elements with this marker in the comment, like @internal (can be set multiple times)")
+	flag.Var(&excludeOptions, "exclude_option", "Exclude elements with this option set to true, like (myorg.internal) (can be set multiple times)")
+	flag.Var(&excludeNames, "exclude_name", "Exclude elements with the full name matching this regular expression (can be set multiple times)")
+	flag.Var(&packages, "package", "Only document this package, \"pkg.*\" also includes subpackages (can be set multiple times)")
+	flag.Var(&excludePackages, "exclude_package", "Don't document this package, \"pkg.*\" also excludes subpackages (can be set multiple times)")
+	flag.Var(&fileGlobs, "file_glob", "Only document proto files matching this glob, like \"myorg/api/**/*.proto\", the paths include the proto path root (can be set multiple times)")
+	flag.Var(&nameRegexes, "name_regex", "Only document elements with the full name matching this regular expression (can be set multiple times)")
+	flag.Var(&kinds, "kind", "Element kinds to document: service, enum, message (can be set multiple times)")
+	flag.Var(&formats, "format", "Output format, as \"name\" or \"name=file\" (can be set multiple times, default html)")
+	flag.Var(&formatOptions, "format_option", "Generator specific option, as \"format.name=value\" (can be set multiple times)")
+	flag.Var(&plugins, "plugin", "External generator executable, as \"name=command\" or \"command\" (can be set multiple times)")
+	flag.Usage = usage
+
+	// the first argument may be a command
+	command := ""
+	if len(os.Args) > 1 && (os.Args[1] == "serve" || os.Args[1] == "formats") {
+		command = os.Args[1]
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+
+	// load the config file
+	cfg := NewConfig()
+	if *configFile != "" {
+		var err error
+		if cfg, err = LoadConfig(*configFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// command line flags override the config file values
+	if err := applyFlags(cfg); err != nil {
+		log.Fatal(err)
+	}
 
-	if *outputPath == "" {
+	if err := cfg.RegisterPlugins(); err != nil {
+		log.Fatal(err)
+	}
+
+	if command == "formats" {
+		listFormats()
+		return
+	}
+
+	if command == "" && len(cfg.Profiles) == 0 && cfg.OutputPath == "" {
 		log.Fatal("The output path is required")
 	}
 
-	// create dependency parser
-	parsedep := fdep.NewDep()
+	// watch mode reports the errors instead of exiting
+	if command == "" && *watchMode {
+		watch(cfg, *pollInterval)
+		return
+	}
 
-	// add include paths
-	parsedep.IncludeDirs = append(parsedep.IncludeDirs, incPaths...)
+	// parse the proto files
+	project, err := NewProject(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// add application proto files
-	for _, pp := range protoPaths {
-		if pp != "" {
-			parse_root := strings.Split(pp, ";")
+	switch command {
+	case "serve":
+		log.Fatal(serve(project, *serveAddr, *pollInterval))
+	default:
+		// generate the documentation of all profiles, sharing the parsed files
+		if err := generateAll(project); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
 
-			parse_file := parse_root[0]
-			parse_curpath := ""
-			if len(parse_root) > 1 {
-				parse_curpath = parse_root[1]
-			}
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage:
+  %[1]s [flags]        generate the documentation
+  %[1]s serve [flags]  serve the documentation with live reload
+  %[1]s formats        list the output formats and their options
+
+Formats: %[2]s
 
-			if s, err := os.Stat(parse_file); err != nil {
-				log.Fatalf("Error reading proto_path: %v", err)
-			} else if !s.IsDir() {
-				log.Fatalf("proto_path isn't a directory: %s", parse_file)
+Flags:
+`, filepath.Base(os.Args[0]), strings.Join(fproto_doc.GeneratorNames(), ", "))
+	flag.PrintDefaults()
+}
+
+// Set the config values from the command line flags that were set.
+// The profile values are set in the default profile and in all the profiles.
+func applyFlags(cfg *Config) error {
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "inc_path":
+			cfg.IncPaths = incPaths
+		case "proto_path":
+			cfg.ProtoPaths = nil
+			for _, pp := range protoPaths {
+				if pp != "" {
+					cfg.ProtoPaths = append(cfg.ProtoPaths, ParseProtoPath(pp))
+				}
+			}
+		case "output_path":
+			if len(cfg.Profiles) > 0 {
+				// all profiles would be generated in the same path
+				err = fmt.Errorf("The output path can't be set when the config file has profiles")
 			}
+			cfg.OutputPath = *outputPath
+		case "source_link":
+			cfg.SourceLink = *sourceLink
+		case "commit":
+			cfg.Commit = *commit
+		case "comment_precedence":
+			cfg.CommentPrecedence = *commentPrecedence
+		case "plugin":
+			cfg.Plugins = nil
+			for _, p := range plugins {
+				cfg.Plugins = append(cfg.Plugins, ParsePlugin(p))
+			}
+		case "internal_output_path":
+			cfg.InternalOutputPath = *internalOutputPath
+		default:
+			for _, p := range append([]*Profile{&cfg.Profile}, cfg.Profiles...) {
+				if perr := applyProfileFlag(p, f.Name); perr != nil {
+					err = perr
+				}
+			}
+		}
+	})
+	return err
+}
 
-			err := parsedep.AddPathWithRoot(parse_curpath, parse_file, fdep.DepType_Own)
-			if err != nil {
-				log.Fatal(err)
+// Set a profile value from the command line flag
+func applyProfileFlag(p *Profile, name string) error {
+	switch name {
+	case "title":
+		p.Title = *title
+	case "theme":
+		p.Theme = *theme
+	case "field_order":
+		p.FieldOrder = *fieldOrder
+	case "exclude_marker":
+		p.ExcludeMarkers = excludeMarkers
+	case "exclude_option":
+		p.ExcludeOptions = excludeOptions
+	case "exclude_name":
+		p.ExcludeNames = excludeNames
+	case "package":
+		p.Packages = packages
+	case "exclude_package":
+		p.ExcludePackages = excludePackages
+	case "file_glob":
+		p.FileGlobs = fileGlobs
+	case "name_regex":
+		p.NameRegexes = nameRegexes
+	case "kind":
+		p.Kinds = kinds
+	case "format":
+		p.Formats = formats
+	case "format_option":
+		for _, fo := range formatOptions {
+			if err := setFormatOption(p, fo); err != nil {
+				return err
 			}
 		}
 	}
+	return nil
+}
 
-	// create output directory
-	if err := os.MkdirAll(*outputPath, os.ModePerm); err != nil {
-		log.Fatalf("Error creating output_path '%s': %v", *outputPath, err)
+// Set a format option from the "format.name=value" format
+func setFormatOption(p *Profile, formatOption string) error {
+	eq := strings.Index(formatOption, "=")
+	dot := strings.Index(formatOption, ".")
+	if eq < 0 || dot < 0 || dot > eq {
+		return fmt.Errorf("Invalid format option, must be \"format.name=value\": %s", formatOption)
 	}
 
-	// create output file
-	outfile, err := os.Create(filepath.Join(*outputPath, "index.html"))
-	if err != nil {
-		log.Fatal("Error creating html file: %v", err)
+	if p.FormatOptions == nil {
+		p.FormatOptions = make(map[string]map[string]string)
 	}
+	format := formatOption[:dot]
+	if p.FormatOptions[format] == nil {
+		p.FormatOptions[format] = make(map[string]string)
+	}
+	p.FormatOptions[format][formatOption[dot+1:eq]] = formatOption[eq+1:]
+	return nil
+}
 
-	defer outfile.Close()
-
-	// creates the HTML generator
-	gen := fproto_doc_html_default.NewGenerator()
-
-	// generate the files
-	err = gen.Generate(parsedep, outfile)
-	if err != nil {
-		log.Fatal(err)
+// Print the registered formats and their options
+func listFormats() {
+	for _, name := range fproto_doc.GeneratorNames() {
+		info, _ := fproto_doc.GetGenerator(name)
+		fmt.Printf("%s: %s (default file: %s)\n", info.Name, info.Description, info.FileName)
+		for _, oi := range info.Options {
+			fmt.Printf("    %s (%s", oi.Name, oi.Type)
+			if oi.Default != "" {
+				fmt.Printf(", default %s", oi.Default)
+			}
+			fmt.Printf("): %s\n", oi.Description)
+		}
 	}
 }
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+	"github.com/RangelReale/fproto-doc"
+)
+
+// Parsed proto files and the documentation settings
+type Project struct {
+	Config            *Config
+	Dep               *fdep.Dep
+	SourceLoader      fproto_doc.SourceLoader
+	SourceLink        *fproto_doc.SourceLink
+	CommentPrecedence fproto_doc.CommentPrecedence
+}
+
+// Creates the project, parsing all the proto files
+func NewProject(cfg *Config) (*Project, error) {
+	cprecedence, err := fproto_doc.ParseCommentPrecedence(cfg.CommentPrecedence)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Project{
+		Config:            cfg,
+		CommentPrecedence: cprecedence,
+	}
+
+	if err := p.Parse(); err != nil {
+		return nil, err
+	}
+
+	// the commit is also shown by the generators without source links
+	if cfg.SourceLink != "" || cfg.Commit != "" {
+		p.SourceLink = &fproto_doc.SourceLink{Template: cfg.SourceLink, Commit: cfg.Commit}
+		for _, pp := range cfg.ProtoPaths {
+			p.SourceLink.Roots = append(p.SourceLink.Roots, fproto_doc.SourceRoot{Dir: pp.Dir, Root: pp.Root})
+		}
+		if p.SourceLink.Commit == "" && strings.Contains(cfg.SourceLink, "{commit}") {
+			if p.SourceLink.Commit, err = p.gitCommit(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Get the current git commit of the first proto path
+func (p *Project) gitCommit() (string, error) {
+	dir := "."
+	if len(p.Config.ProtoPaths) > 0 {
+		dir = p.Config.ProtoPaths[0].Dir
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Error reading the git commit of '%s', set the commit in the configuration: %v", dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Parse all the proto files
+func (p *Project) Parse() error {
+	// create dependency parser
+	parsedep := fdep.NewDep()
+
+	// source roots, to load the proto source files
+	var sourceRoots []fproto_doc.SourceRoot
+
+	// add include paths
+	parsedep.IncludeDirs = append(parsedep.IncludeDirs, p.Config.IncPaths...)
+	for _, ip := range p.Config.IncPaths {
+		sourceRoots = append(sourceRoots, fproto_doc.SourceRoot{Dir: ip})
+	}
+
+	// add application proto files
+	for _, pp := range p.Config.ProtoPaths {
+		if s, err := os.Stat(pp.Dir); err != nil {
+			return fmt.Errorf("Error reading proto_path: %v", err)
+		} else if !s.IsDir() {
+			return fmt.Errorf("proto_path isn't a directory: %s", pp.Dir)
+		}
+
+		err := parsedep.AddPathWithRoot(pp.Root, pp.Dir, fdep.DepType_Own)
+		if err != nil {
+			return err
+		}
+
+		sourceRoots = append(sourceRoots, fproto_doc.SourceRoot{Dir: pp.Dir, Root: pp.Root})
+	}
+
+	p.Dep = parsedep
+	p.SourceLoader = fproto_doc.NewDirSourceLoader(sourceRoots)
+	return nil
+}
+
+// Re-parse only the changed proto files. Files of include paths are only
+// re-parsed if they were already imported.
+func (p *Project) ReparseFiles(changed []string, removed []string) error {
+	for _, fn := range removed {
+		if filePath, _, ok := p.depFilePath(fn); ok {
+			p.removeFile(filePath)
+		}
+	}
+
+	var errs []string
+	for _, fn := range changed {
+		filePath, deptype, ok := p.depFilePath(fn)
+		if !ok {
+			continue
+		}
+		if _, exists := p.Dep.Files[filePath]; deptype == fdep.DepType_Imported && !exists {
+			continue
+		}
+
+		if err := p.reparseFile(fn, filePath, deptype); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", fn, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Error parsing proto files:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func (p *Project) reparseFile(filename string, filePath string, deptype fdep.FileDepType) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	pfile, err := fproto.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	p.removeFile(filePath)
+	return p.Dep.AddProtoFile(filePath, pfile, deptype)
+}
+
+func (p *Project) removeFile(filePath string) {
+	f, ok := p.Dep.Files[filePath]
+	if !ok {
+		return
+	}
+	delete(p.Dep.Files, filePath)
+
+	pkg := f.ProtoFile.PackageName
+	var pfiles []string
+	for _, pf := range p.Dep.Packages[pkg] {
+		if pf != filePath {
+			pfiles = append(pfiles, pf)
+		}
+	}
+	if len(pfiles) > 0 {
+		p.Dep.Packages[pkg] = pfiles
+	} else {
+		delete(p.Dep.Packages, pkg)
+	}
+}
+
+// Get the fdep file path of a file on disk
+func (p *Project) depFilePath(filename string) (string, fdep.FileDepType, bool) {
+	for _, pp := range p.Config.ProtoPaths {
+		if rel, err := filepath.Rel(pp.Dir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return path.Join(filepath.ToSlash(pp.Root), filepath.ToSlash(rel)), fdep.DepType_Own, true
+		}
+	}
+	for _, ip := range p.Config.IncPaths {
+		if rel, err := filepath.Rel(ip, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel), fdep.DepType_Imported, true
+		}
+	}
+	return "", fdep.DepType_Own, false
+}
+
+// Profiles to generate, the default profile is used if the config file has none
+func (p *Project) Profiles() []*Profile {
+	if len(p.Config.Profiles) > 0 {
+		return p.Config.Profiles
+	}
+
+	profiles := []*Profile{&p.Config.Profile}
+
+	// internal documentation, without the exclusion rules
+	if p.Config.InternalOutputPath != "" {
+		internal := p.Config.Profile
+		internal.Name = "internal"
+		internal.OutputPath = p.Config.InternalOutputPath
+		internal.ExcludeMarkers = nil
+		internal.ExcludeOptions = nil
+		internal.ExcludeNames = nil
+		profiles = append(profiles, &internal)
+	}
+
+	return profiles
+}
+
+// Generate the documentation of the profile in the format. If create is not nil,
+// generators that support it also write other files.
+func (p *Project) Generate(profile *Profile, format *Format, w io.Writer, create fproto_doc.FileCreator) error {
+	filter, err := profile.Filter()
+	if err != nil {
+		return err
+	}
+
+	styleSheet, err := profile.StyleSheet()
+	if err != nil {
+		return err
+	}
+
+	fieldOrder, err := fproto_doc.ParseFieldOrder(profile.FieldOrder)
+	if err != nil {
+		return err
+	}
+
+	info, err := getGenerator(format.Name)
+	if err != nil {
+		return err
+	}
+
+	options := fproto_doc.NewGeneratorOptions()
+	options.SourceLoader = p.SourceLoader
+	options.CommentPrecedence = p.CommentPrecedence
+	options.FieldOrder = fieldOrder
+	options.Filter = filter
+	options.StyleSheet = styleSheet
+	options.Links = p.Config.Links
+	options.SourceLink = p.SourceLink
+	if profile.Title != "" {
+		options.Title = profile.Title
+	}
+	for name, value := range profile.FormatOptions[format.Name] {
+		options.Values[name] = value
+	}
+
+	// creates the generator
+	gen, err := info.NewGenerator(options)
+	if err != nil {
+		return err
+	}
+
+	// generate the files
+	if mgen, ok := gen.(fproto_doc.MultiFileGenerator); ok && create != nil {
+		return mgen.GenerateFiles(p.Dep, w, create)
+	}
+	return gen.Generate(p.Dep, w)
+}
+
+// Generate the documentation of the profile in all its formats in its output path.
+// The files are written atomically, replacing the previous ones only if the generation succeeds.
+func (p *Project) GenerateFiles(profile *Profile) error {
+	formats, err := profile.OutputFormats()
+	if err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		if err := p.generateFile(profile, format); err != nil {
+			return fmt.Errorf("Error generating %s of profile '%s': %v", format.Name, profile.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Project) generateFile(profile *Profile, format *Format) error {
+	filename := filepath.Join(profile.OutputPath, filepath.FromSlash(format.FileName))
+
+	// create output directory
+	outputDir := filepath.Dir(filename)
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return fmt.Errorf("Error creating output path '%s': %v", outputDir, err)
+	}
+
+	// create temporary output file
+	outfile, err := ioutil.TempFile(outputDir, "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return fmt.Errorf("Error creating output file: %v", err)
+	}
+
+	// other files of the generator, also written to temporary files
+	var others []*os.File
+	var otherNames []string
+	removeOthers := func() {
+		for _, f := range others {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}
+	create := func(fileName string) (io.Writer, error) {
+		otherName := filepath.Join(outputDir, filepath.FromSlash(fileName))
+		if err := os.MkdirAll(filepath.Dir(otherName), os.ModePerm); err != nil {
+			return nil, err
+		}
+		f, err := ioutil.TempFile(filepath.Dir(otherName), "."+filepath.Base(otherName)+"-")
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, f)
+		otherNames = append(otherNames, otherName)
+		return f, nil
+	}
+
+	err = p.Generate(profile, format, outfile, create)
+	if cerr := outfile.Close(); err == nil {
+		err = cerr
+	}
+	for _, f := range others {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		os.Remove(outfile.Name())
+		removeOthers()
+		return err
+	}
+
+	for i, f := range others {
+		if err := os.Chmod(f.Name(), 0644); err != nil {
+			os.Remove(outfile.Name())
+			removeOthers()
+			return err
+		}
+		if err := os.Rename(f.Name(), otherNames[i]); err != nil {
+			os.Remove(outfile.Name())
+			removeOthers()
+			return err
+		}
+	}
+
+	if err := os.Chmod(outfile.Name(), 0644); err != nil {
+		os.Remove(outfile.Name())
+		return err
+	}
+
+	return os.Rename(outfile.Name(), filename)
+}
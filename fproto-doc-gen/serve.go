@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Serves the generated documentation from memory, regenerating it when the proto files change
+type Server struct {
+	project *Project
+
+	mu      sync.RWMutex
+	pages   map[string][]byte // generated pages by URL path
+	version int
+	changed *sync.Cond
+}
+
+func NewServer(project *Project) *Server {
+	s := &Server{
+		project: project,
+		pages:   make(map[string][]byte),
+	}
+	s.changed = sync.NewCond(s.mu.RLocker())
+	return s
+}
+
+// Generate all the profiles in memory
+func (s *Server) Generate() error {
+	pages := make(map[string][]byte)
+
+	profiles := s.project.Profiles()
+	for _, p := range profiles {
+		formats, err := p.OutputFormats()
+		if err != nil {
+			return err
+		}
+
+		// with only one profile it is served on the root
+		urlPath := "/"
+		if len(profiles) > 1 {
+			urlPath = "/" + p.Name + "/"
+		}
+
+		for _, format := range formats {
+			// other files of the generator, relative to the main file
+			others := make(map[string]*bytes.Buffer)
+			create := func(fileName string) (io.Writer, error) {
+				buf := &bytes.Buffer{}
+				others[path.Join(urlPath, path.Dir(format.FileName), fileName)] = buf
+				return buf, nil
+			}
+
+			var buf bytes.Buffer
+			if err := s.project.Generate(p, format, &buf, create); err != nil {
+				return fmt.Errorf("Error generating %s of profile '%s': %v", format.Name, p.Name, err)
+			}
+			for otherPath, otherBuf := range others {
+				pages[otherPath] = otherBuf.Bytes()
+			}
+
+			page := buf.Bytes()
+			if path.Ext(format.FileName) == ".html" {
+				page = injectLiveReload(page)
+			}
+			pages[urlPath+format.FileName] = page
+		}
+	}
+
+	s.mu.Lock()
+	s.pages = pages
+	s.version++
+	s.mu.Unlock()
+
+	s.changed.Broadcast()
+	return nil
+}
+
+// Watch the proto directories, regenerating the documentation on changes
+func (s *Server) Watch(interval time.Duration) {
+	watcher := NewWatcher(s.project.Config.WatchDirs())
+	watcher.Watch(interval, func(changed []string, removed []string) bool {
+		log.Printf("Proto files changed: %d changed, %d removed", len(changed), len(removed))
+
+		if err := s.project.ReparseFiles(changed, removed); err != nil {
+			log.Println(err)
+			return true
+		}
+		if err := s.Generate(); err != nil {
+			log.Println(err)
+		}
+		return true
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == liveReloadPath {
+		s.serveLiveReload(w, r)
+		return
+	}
+
+	urlPath := r.URL.Path
+	if strings.HasSuffix(urlPath, "/") {
+		urlPath += "index.html"
+	}
+
+	s.mu.RLock()
+	page, ok := s.pages[urlPath]
+	var pageList []string
+	if !ok && urlPath == "/index.html" {
+		for pp := range s.pages {
+			pageList = append(pageList, pp)
+		}
+	}
+	s.mu.RUnlock()
+
+	if ok {
+		ctype := mime.TypeByExtension(path.Ext(urlPath))
+		if ctype == "" {
+			ctype = "text/plain; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", ctype)
+		w.Write(page)
+		return
+	}
+
+	// list the generated files on the root
+	if urlPath == "/index.html" {
+		sort.Strings(pageList)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<!DOCTYPE html><html><body><ul>")
+		for _, pp := range pageList {
+			fmt.Fprintf(w, `<li><a href="%s">%s</a></li>`, pp, strings.TrimPrefix(pp, "/"))
+		}
+		fmt.Fprint(w, "</ul></body></html>")
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// Sends an event each time the documentation is regenerated
+func (s *Server) serveLiveReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	// wake up the waiting loop when the client disconnects
+	done := r.Context().Done()
+	go func() {
+		<-done
+		// locking ensures the loop is either waiting or will see the done state
+		s.mu.Lock()
+		s.mu.Unlock()
+		s.changed.Broadcast()
+	}()
+
+	isDone := func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+
+	s.mu.RLock()
+	version := s.version
+	for {
+		for s.version == version && !isDone() {
+			s.changed.Wait()
+		}
+		if isDone() {
+			s.mu.RUnlock()
+			return
+		}
+		version = s.version
+		s.mu.RUnlock()
+
+		if _, err := fmt.Fprint(w, "data: reload\n\n"); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		s.mu.RLock()
+	}
+}
+
+const liveReloadPath = "/__livereload"
+
+// Adds the live reload script to the page
+func injectLiveReload(page []byte) []byte {
+	script := []byte(`<script>new EventSource("` + liveReloadPath + `").onmessage = function() { location.reload(); };</script>
+</body>`)
+	return bytes.Replace(page, []byte("</body>"), script, 1)
+}
+
+// Runs the preview server
+func serve(project *Project, addr string, interval time.Duration) error {
+	server := NewServer(project)
+	if err := server.Generate(); err != nil {
+		return err
+	}
+
+	go server.Watch(interval)
+
+	log.Printf("Serving documentation on http://%s/", addr)
+	return http.ListenAndServe(addr, server)
+}
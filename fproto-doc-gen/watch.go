@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Generate the documentation of all profiles
+func generateAll(project *Project) error {
+	for _, p := range project.Profiles() {
+		if err := project.GenerateFiles(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate the documentation of all profiles, reporting the result of each one
+func generateAllReport(project *Project) {
+	for _, p := range project.Profiles() {
+		if err := project.GenerateFiles(p); err != nil {
+			log.Println(err)
+		} else {
+			log.Printf("Generated profile '%s' in %s", p.Name, p.OutputPath)
+		}
+	}
+}
+
+// Keeps regenerating the documentation when the proto files change.
+// Errors are reported and the watch continues.
+func watch(cfg *Config, interval time.Duration) {
+	project, err := NewProject(cfg)
+	if err != nil {
+		log.Println(err)
+	} else {
+		generateAllReport(project)
+	}
+
+	log.Printf("Watching for changes: %s", strings.Join(cfg.WatchDirs(), ", "))
+
+	watcher := NewWatcher(cfg.WatchDirs())
+	watcher.Watch(interval, func(changed []string, removed []string) bool {
+		if len(changed) > 0 {
+			log.Printf("Changed: %s", strings.Join(changed, ", "))
+		}
+		if len(removed) > 0 {
+			log.Printf("Removed: %s", strings.Join(removed, ", "))
+		}
+
+		if project == nil {
+			// the initial parse failed, parse everything again
+			if project, err = NewProject(cfg); err != nil {
+				log.Println(err)
+				return true
+			}
+		} else if err := project.ReparseFiles(changed, removed); err != nil {
+			log.Println(err)
+			return true
+		}
+
+		generateAllReport(project)
+		return true
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Watches directories for changes in proto files, by polling the modification times
+type Watcher struct {
+	dirs  []string
+	files map[string]time.Time
+}
+
+func NewWatcher(dirs []string) *Watcher {
+	w := &Watcher{
+		dirs: dirs,
+	}
+	w.files = w.scan()
+	return w
+}
+
+// Returns the proto files changed or removed since the last call
+func (w *Watcher) Changes() (changed []string, removed []string) {
+	files := w.scan()
+
+	for fn, mt := range files {
+		if omt, ok := w.files[fn]; !ok || !omt.Equal(mt) {
+			changed = append(changed, fn)
+		}
+	}
+	for fn := range w.files {
+		if _, ok := files[fn]; !ok {
+			removed = append(removed, fn)
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Strings(removed)
+
+	w.files = files
+	return
+}
+
+// Calls the function each time proto files change, until it returns false
+func (w *Watcher) Watch(interval time.Duration, f func(changed []string, removed []string) bool) {
+	for {
+		time.Sleep(interval)
+		changed, removed := w.Changes()
+		if len(changed) == 0 && len(removed) == 0 {
+			continue
+		}
+		if !f(changed, removed) {
+			return
+		}
+	}
+}
+
+func (w *Watcher) scan() map[string]time.Time {
+	ret := make(map[string]time.Time)
+	for _, dir := range w.dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// the file may have been removed while walking
+				return nil
+			}
+			if !info.IsDir() && filepath.Ext(path) == ".proto" {
+				ret[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return ret
+}
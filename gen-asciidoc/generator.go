@@ -0,0 +1,222 @@
+package fproto_doc_asciidoc
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto-doc"
+)
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "asciidoc",
+		Description: "AsciiDoc documentation, for Asciidoctor",
+		FileName:    "api.adoc",
+		Options: []*fproto_doc.GeneratorOptionInfo{
+			{Name: "toc", Type: fproto_doc.GOT_BOOL, Default: "true", Description: "Add the table of contents attribute to the document header"},
+			{Name: "level", Type: fproto_doc.GOT_INT, Default: "0", Description: "Section level of the title, use 1 or more to include the document into a larger book"},
+		},
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates the documentation as AsciiDoc. With a level greater than 0 the document
+// has no header, and can be included into a larger document with "include::".
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	helper := g.Options.NewHelper(dep)
+
+	model, err := helper.BuildModel(g.Options.Filter, g.Options.CommentPrecedence, g.Options.FieldOrder)
+	if err != nil {
+		return err
+	}
+
+	level := 0
+	if v := g.Options.Value("level"); v != "" {
+		if level, err = strconv.Atoi(v); err != nil {
+			return err
+		}
+	}
+	if level < 0 {
+		return fmt.Errorf("The level can't be negative: %d", level)
+	}
+
+	aw := &adocWriter{w: w, level: level, types: make(map[string]fproto_doc.AnchorKind)}
+	for _, p := range model.Packages {
+		for _, e := range p.Enums {
+			aw.types[e.FullName] = fproto_doc.AK_ENUM
+		}
+		for _, m := range p.Messages {
+			aw.types[m.FullName] = fproto_doc.AK_MESSAGE
+		}
+	}
+
+	aw.title(0, "", g.Options.Title)
+	if level == 0 {
+		// document header
+		if g.Options.BoolValue("toc") {
+			aw.printf(":toc:\n:toclevels: 3\n")
+		}
+		aw.printf(":idprefix:\n:sectanchors:\n")
+	}
+	aw.printf("\n")
+
+	for _, p := range model.Packages {
+		aw.title(1, fproto_doc.Anchor(fproto_doc.AK_PACKAGE, p.Name), p.Name)
+
+		for _, s := range p.Services {
+			aw.writeService(s)
+		}
+		for _, e := range p.Enums {
+			aw.writeEnum(e)
+		}
+		for _, m := range p.Messages {
+			aw.writeMessage(m)
+		}
+	}
+
+	return aw.err
+}
+
+type adocWriter struct {
+	w     io.Writer
+	err   error
+	level int                              // section level of the title
+	types map[string]fproto_doc.AnchorKind // kind of the documented types
+}
+
+func (aw *adocWriter) printf(format string, a ...interface{}) {
+	if aw.err != nil {
+		return
+	}
+	_, aw.err = fmt.Fprintf(aw.w, format, a...)
+}
+
+// Write a section title, relative to the level of the document title
+func (aw *adocWriter) title(level int, anchor string, title string) {
+	if anchor != "" {
+		aw.printf("[[%s]]\n", anchor)
+	}
+	aw.printf("%s %s\n", strings.Repeat("=", aw.level+level+1), text(title))
+}
+
+func (aw *adocWriter) writeHeader(kind fproto_doc.AnchorKind, name string, fullName string, file string, description string) {
+	aw.title(2, fproto_doc.Anchor(kind, fullName), strings.ToUpper(string(kind[:1]))+string(kind[1:])+" "+name)
+	aw.printf("\n`%s` [%s]\n\n", fullName, text(file))
+	if description != "" {
+		aw.printf("%s\n\n", text(description))
+	}
+}
+
+func (aw *adocWriter) writeService(s *fproto_doc.ModelService) {
+	aw.writeHeader(fproto_doc.AK_SERVICE, s.Name, s.FullName, s.File, s.Description)
+
+	aw.printf("[cols=\"2,2,2,4\",options=\"header\"]\n|===\n")
+	aw.printf("|Method name |Request Type |Response Type |Description\n")
+	for _, rpc := range s.RPCs {
+		req_type := aw.typeLink(rpc.RequestType, rpc.RequestFullType)
+		if rpc.RequestStreaming {
+			req_type = "stream " + req_type
+		}
+		resp_type := aw.typeLink(rpc.ResponseType, rpc.ResponseFullType)
+		if rpc.ResponseStreaming {
+			resp_type = "stream " + resp_type
+		}
+		aw.printf("\n|[[%s]]%s\n|%s\n|%s\n|%s\n", fproto_doc.Anchor(fproto_doc.AK_RPC, s.FullName+"."+rpc.Name), rpc.Name,
+			req_type, resp_type, cell(rpc.Description))
+	}
+	aw.printf("|===\n\n")
+}
+
+func (aw *adocWriter) writeEnum(e *fproto_doc.ModelEnum) {
+	aw.writeHeader(fproto_doc.AK_ENUM, e.Name, e.FullName, e.File, e.Description)
+
+	aw.printf("[cols=\"3,1,6\",options=\"header\"]\n|===\n")
+	aw.printf("|Name |Value |Description\n")
+	for _, v := range e.Values {
+		aw.printf("\n|[[%s]]%s\n|%d\n|%s\n", fproto_doc.Anchor(fproto_doc.AK_ENUM_VALUE, e.FullName+"."+v.Name), v.Name,
+			v.Number, cell(v.Description))
+	}
+	aw.printf("|===\n\n")
+}
+
+func (aw *adocWriter) writeMessage(m *fproto_doc.ModelMessage) {
+	aw.writeHeader(fproto_doc.AK_MESSAGE, m.Name, m.FullName, m.File, m.Description)
+
+	aw.printf("[cols=\"2,2,2,4\",options=\"header\"]\n|===\n")
+	aw.printf("|Fieldname |Type |Flags |Description\n")
+	for _, f := range m.Fields {
+		ftype := aw.typeLink(f.Type, f.FullType)
+		if f.KeyType != "" {
+			if strings.HasPrefix(ftype, "<<") {
+				// passthroughs, so the brackets aren't taken as part of the cross reference
+				ftype = fmt.Sprintf("+map<+%s, %s+>+", f.KeyType, ftype)
+			} else {
+				ftype = fmt.Sprintf("map<%s, %s>", f.KeyType, ftype)
+			}
+		}
+
+		var flags []string
+		if f.Label != "" {
+			flags = append(flags, f.Label)
+		}
+		if f.Oneof != "" {
+			flags = append(flags, fmt.Sprintf("<<%s,oneof %s>>", fproto_doc.Anchor(fproto_doc.AK_ONEOF, m.FullName+"."+f.Oneof), f.Oneof))
+		}
+		for _, v := range f.Validation {
+			if v.Text != "" {
+				flags = append(flags, cell(v.Text))
+			}
+		}
+
+		aw.printf("\n|[[%s]]%s\n|%s\n|%s\n|%s\n", fproto_doc.Anchor(fproto_doc.AK_FIELD, m.FullName+"."+f.Name), f.Name,
+			ftype, strings.Join(flags, ", "), cell(f.Description))
+	}
+	aw.printf("|===\n\n")
+
+	for _, o := range m.Oneofs {
+		aw.title(3, fproto_doc.Anchor(fproto_doc.AK_ONEOF, m.FullName+"."+o.Name), "Oneof "+m.Name+"."+o.Name)
+		aw.printf("\n")
+		if o.Description != "" {
+			aw.printf("%s\n\n", text(o.Description))
+		}
+
+		var fields []string
+		for _, f := range o.Fields {
+			fields = append(fields, fmt.Sprintf("<<%s,%s>>", fproto_doc.Anchor(fproto_doc.AK_FIELD, m.FullName+"."+f), f))
+		}
+		aw.printf("Only one of the fields may be set: %s\n\n", strings.Join(fields, ", "))
+	}
+}
+
+// Cross reference to the type if it is documented
+func (aw *adocWriter) typeLink(typeName string, fullType string) string {
+	if kind, ok := aw.types[fullType]; ok {
+		return fmt.Sprintf("<<%s,%s>>", fproto_doc.Anchor(kind, fullType), typeName)
+	}
+	return typeName
+}
+
+// Escape the attribute references of a text
+func text(s string) string {
+	return strings.Replace(s, "{", "\\{", -1)
+}
+
+// Escape text to be used inside a table cell
+func cell(s string) string {
+	return strings.Replace(text(s), "|", "\\|", -1)
+}
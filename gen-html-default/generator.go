@@ -1,123 +1,166 @@
 package fproto_doc_html_default
 
 import (
-	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"github.com/RangelReale/fdep"
 	"github.com/RangelReale/fproto"
 	"github.com/RangelReale/fproto-doc"
-	"github.com/gosimple/slug"
 )
 
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "html",
+		Description: "Single page HTML documentation",
+		FileName:    "index.html",
+		Options:     optionsInfo,
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			htmlOptions, err := NewOptionsFromGeneratorOptions(options)
+			if err != nil {
+				return nil, err
+			}
+			return &Generator{Options: options, HTML: htmlOptions}, nil
+		},
+	})
+}
+
 type Generator struct {
+	// Generator options, the sort and dependency types of the filter are ignored
+	Options *fproto_doc.GeneratorOptions
+
+	// HTML options
+	HTML *Options
 }
 
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+		HTML:    NewOptions(),
+	}
 }
 
 func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
-	layout := &Layout{w: w}
+	helper := g.Options.NewHelper(dep)
+
+	layout := &Layout{w: w, helper: helper, options: g.HTML, commentPrecedence: g.Options.CommentPrecedence, filter: g.getFilter(), links: g.Options.Links, sourceLink: g.Options.SourceLink}
 
-	helper := fproto_doc.NewHelper(dep)
+	title := g.HTML.Title
+	if title == "" {
+		title = g.Options.Title
+	}
 
 	//
 	// HEADER
 	//
-	layout.WriteHeader()
+	layout.WriteHeader(title, g.Options.StyleSheet)
 
-	type litem struct {
-		layoutItem layoutItem
-		list       []*fdep.DepType
+	var llist []*litem
+	if g.Options.Filter.IsKindSelected(fproto_doc.EK_SERVICE) && g.HTML.IsSectionEnabled(SEC_SERVICES) {
+		llist = append(llist, &litem{layoutItem: li_service, list: helper.GetServiceList(g.getFilter())})
 	}
-
-	llist := []*litem{
-		{layoutItem: li_service, list: helper.GetServiceList(fproto_doc.NewGetFilter(fproto_doc.ST_ALIAS_NAME, fproto_doc.DT_OWN))},
-		{layoutItem: li_enum, list: helper.GetEnumList(fproto_doc.NewGetFilter(fproto_doc.ST_ALIAS_NAME, fproto_doc.DT_OWN))},
-		{layoutItem: li_message, list: helper.GetMessageList(fproto_doc.NewGetFilter(fproto_doc.ST_ALIAS_NAME, fproto_doc.DT_OWN))},
+	if g.Options.Filter.IsKindSelected(fproto_doc.EK_ENUM) && g.HTML.IsSectionEnabled(SEC_ENUMS) {
+		llist = append(llist, &litem{layoutItem: li_enum, list: helper.GetEnumList(g.getFilter())})
+	}
+	if g.Options.Filter.IsKindSelected(fproto_doc.EK_MESSAGE) && g.HTML.IsSectionEnabled(SEC_MESSAGES) {
+		llist = append(llist, &litem{layoutItem: li_message, list: helper.GetMessageList(g.getFilter())})
 	}
 
 	last_alias := ""
-	slug_ns := ""
 
 	//
 	// NAV
 	//
-	layout.WriteNav(LS_BEGIN)
+	if g.HTML.IsSectionEnabled(SEC_NAV) {
+		layout.WriteNav(LS_BEGIN)
 
-	for _, li := range llist {
-		layout.WriteNavItem(LS_BEGIN, li.layoutItem.Title(), fmt.Sprintf("content-%s", li.layoutItem.String()))
+		for _, li := range llist {
+			layout.WriteNavItem(LS_BEGIN, li.layoutItem.Title(), li.layoutItem.Anchor(""))
 
-		last_alias = ""
-		slug_ns = ""
-		for _, e := range li.list {
-			if e.Alias != last_alias {
-				if last_alias != "" {
-					layout.WriteNavNs(LS_END, last_alias, "")
-				}
+			last_alias = ""
+			for _, e := range li.list {
+				if e.Alias != last_alias {
+					if last_alias != "" {
+						layout.WriteNavNs(LS_END, last_alias, "")
+					}
 
-				slug_ns = slug.Make(e.Alias)
+					layout.WriteNavNs(LS_BEGIN, e.Alias, li.layoutItem.Anchor(e.Alias))
+					last_alias = e.Alias
+				}
 
-				layout.WriteNavNs(LS_BEGIN, e.Alias, fmt.Sprintf("content-%s-%s", li.layoutItem.String(), slug_ns))
-				last_alias = e.Alias
+				layout.WriteNavNsItem(LS_BEGIN, e.Name, fproto_doc.TypeAnchor(e))
+				layout.WriteNavNsItem(LS_END, e.Name, "")
+			}
+			if last_alias != "" {
+				layout.WriteNavNs(LS_END, last_alias, "")
 			}
 
-			slug_nsitem := slug.Make(e.Name)
+			layout.WriteNavItem(LS_END, li.layoutItem.String(), "")
+		}
 
-			layout.WriteNavNsItem(LS_BEGIN, e.Name, fmt.Sprintf("content-%s-%s-%s", li.layoutItem.String(), slug_ns, slug_nsitem))
-			layout.WriteNavNsItem(LS_END, e.Name, "")
+		if g.HTML.WellKnownTypes {
+			layout.WriteNavItem(LS_BEGIN, "Well-known types", fproto_doc.SectionAnchor("wellknown", ""))
+			layout.WriteNavItem(LS_END, "Well-known types", "")
 		}
-		if last_alias != "" {
-			layout.WriteNavNs(LS_END, last_alias, "")
+		if g.HTML.ScalarTypes {
+			layout.WriteNavItem(LS_BEGIN, "Scalar value types", fproto_doc.SectionAnchor("scalars", ""))
+			layout.WriteNavItem(LS_END, "Scalar value types", "")
+		}
+		if g.HTML.ShowSource {
+			layout.WriteNavItem(LS_BEGIN, "Sources", fproto_doc.SectionAnchor("sources", ""))
+			for _, fn := range g.sourceFiles(helper, llist) {
+				layout.WriteNavNsItem(LS_BEGIN, fn, fproto_doc.SourceAnchor(fn, 0))
+				layout.WriteNavNsItem(LS_END, fn, "")
+			}
+			layout.WriteNavItem(LS_END, "Sources", "")
 		}
 
-		layout.WriteNavItem(LS_END, li.layoutItem.String(), "")
+		layout.WriteNav(LS_END)
 	}
 
-	layout.WriteNav(LS_END)
-
 	//
 	// CONTENT
 	//
-	layout.WriteContent(LS_BEGIN)
+	layout.WriteContent(LS_BEGIN, title)
 
 	for _, li := range llist {
-		layout.WriteContentItem(LS_BEGIN, li.layoutItem.Title(), fmt.Sprintf("content-%s", li.layoutItem.String()))
+		layout.WriteContentItem(LS_BEGIN, li.layoutItem.Title(), li.layoutItem.Anchor(""))
 
 		last_alias = ""
-		slug_ns = ""
 		for _, e := range li.list {
 			if e.Alias != last_alias {
 				if last_alias != "" {
 					layout.WriteContentNs(LS_END, last_alias, "")
 				}
 
-				slug_ns = slug.Make(e.Alias)
-
-				layout.WriteContentNs(LS_BEGIN, e.Alias, fmt.Sprintf("content-%s-%s", li.layoutItem.String(), slug_ns))
+				layout.WriteContentNs(LS_BEGIN, e.Alias, li.layoutItem.Anchor(e.Alias))
 				last_alias = e.Alias
 			}
 
-			slug_nsitem := slug.Make(e.Name)
 			fn := ""
 			if e.DepFile != nil {
 				fn = e.DepFile.FilePath
 			}
 
-			layout.WriteContentNsItem(LS_BEGIN, e.Name, fmt.Sprintf("content-%s-%s-%s", li.layoutItem.String(), slug_ns, slug_nsitem), fn, e.Alias)
+			layout.WriteContentNsItem(LS_BEGIN, e.Name, fproto_doc.TypeAnchor(e), fn, e.Alias, helper.SourceURL(g.Options.SourceLink, e, ""))
 
 			switch li.layoutItem {
 			case li_service:
 				layout.WriteContentService(e)
+				layout.WriteContentSource(e)
 			case li_enum:
 				layout.WriteContentEnum(e)
+				layout.WriteContentSource(e)
 			case li_message:
 				layout.WriteContentMessage(e)
-				layout.WriteContentOneofFields(e, helper.GetOneOfFieldList(e.Item.(*fproto.MessageElement).Fields))
+				layout.WriteContentSource(e)
+				if g.HTML.IsSectionEnabled(SEC_ONEOFS) {
+					layout.WriteContentOneofFields(e, helper.GetOneOfFieldList(helper.OrderedFieldList(helper.FilterFieldList(e, e.Item.(*fproto.MessageElement).Fields, g.Options.Filter.Exclude), g.HTML.FieldOrder)))
+				}
 			}
 
-			layout.WriteContentNsItem(LS_END, e.Name, "", "", "")
+			layout.WriteContentNsItem(LS_END, e.Name, "", "", "", "")
 		}
 		if last_alias != "" {
 			layout.WriteContentNs(LS_END, last_alias, "")
@@ -126,7 +169,28 @@ func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
 		layout.WriteContentItem(LS_END, li.layoutItem.Title(), "")
 	}
 
-	layout.WriteContent(LS_END)
+	layout.WriteContent(LS_END, "")
+
+	//
+	// WELL-KNOWN TYPES
+	//
+	if g.HTML.WellKnownTypes {
+		layout.WriteWellKnownTypes()
+	}
+
+	//
+	// SCALAR VALUE TYPES
+	//
+	if g.HTML.ScalarTypes {
+		layout.WriteScalarTypes()
+	}
+
+	//
+	// SOURCES
+	//
+	if g.HTML.ShowSource {
+		layout.WriteSourceFiles(g.sourceFiles(helper, llist))
+	}
 
 	//
 	// FOOTER
@@ -136,6 +200,34 @@ func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
 	return layout.Err()
 }
 
+// Files of the listed elements that have the source available, sorted
+func (g *Generator) sourceFiles(helper *fproto_doc.Helper, llist []*litem) []string {
+	files := make(map[string]bool)
+	for _, li := range llist {
+		for _, e := range li.list {
+			if e.DepFile != nil && helper.GetSourceFile(e.DepFile.FilePath) != nil {
+				files[e.DepFile.FilePath] = true
+			}
+		}
+	}
+
+	var ret []string
+	for fn := range files {
+		ret = append(ret, fn)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (g *Generator) getFilter() *fproto_doc.GetFilter {
+	return g.Options.Filter.With(g.HTML.SortType, g.HTML.FilterDepType)
+}
+
+type litem struct {
+	layoutItem layoutItem
+	list       []*fdep.DepType
+}
+
 type layoutItem int
 
 const (
@@ -156,6 +248,11 @@ func (li layoutItem) String() string {
 	return "Unknown"
 }
 
+// Anchor of the section, or of a package inside it
+func (li layoutItem) Anchor(pkg string) string {
+	return fproto_doc.SectionAnchor(strings.ToLower(li.Title()), pkg)
+}
+
 func (li layoutItem) Title() string {
 	switch li {
 	case li_service:
@@ -0,0 +1,103 @@
+package fproto_doc_html_default
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+var (
+	hl_keywords = map[string]bool{
+		"syntax": true, "package": true, "import": true, "public": true, "weak": true, "option": true,
+		"message": true, "enum": true, "service": true, "rpc": true, "returns": true, "stream": true,
+		"repeated": true, "optional": true, "required": true, "oneof": true, "map": true, "extend": true,
+		"extensions": true, "reserved": true, "to": true, "max": true, "true": true, "false": true,
+	}
+	hl_types = map[string]bool{
+		"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+		"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+		"bool": true, "string": true, "bytes": true,
+	}
+)
+
+// Highlights a proto source, returning the HTML of each line
+func highlightProto(source string) []string {
+	var lines []string
+	var cur strings.Builder
+
+	// write the text, closing and reopening the span on line breaks
+	emit := func(class string, text string) {
+		for i, part := range strings.Split(text, "\n") {
+			if i > 0 {
+				lines = append(lines, cur.String())
+				cur.Reset()
+			}
+			if part == "" {
+				continue
+			}
+			if class == "" {
+				cur.WriteString(html.EscapeString(part))
+			} else {
+				cur.WriteString(`<span class="hl-` + class + `">` + html.EscapeString(part) + `</span>`)
+			}
+		}
+	}
+
+	src := []rune(strings.Replace(source, "\r\n", "\n", -1))
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		start := i
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			emit("comment", string(src[start:i]))
+			i--
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			for i += 2; i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/'); i++ {
+			}
+			if i += 2; i > len(src) {
+				i = len(src)
+			}
+			emit("comment", string(src[start:i]))
+			i--
+		case c == '"' || c == '\'':
+			for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(src) && src[i] == c {
+				i++
+			}
+			emit("string", string(src[start:i]))
+			i--
+		case unicode.IsDigit(c):
+			for i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			emit("number", string(src[start:i]))
+			i--
+		case c == '_' || unicode.IsLetter(c):
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(src[i]) || unicode.IsDigit(src[i])) {
+				i++
+			}
+			word := string(src[start:i])
+			switch {
+			case hl_keywords[word]:
+				emit("keyword", word)
+			case hl_types[word]:
+				emit("type", word)
+			default:
+				emit("", word)
+			}
+			i--
+		default:
+			emit("", string(c))
+		}
+	}
+	lines = append(lines, cur.String())
+
+	return lines
+}
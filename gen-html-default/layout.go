@@ -1,14 +1,16 @@
 package fproto_doc_html_default
 
 import (
+	"bytes"
 	"fmt"
 	"html"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/RangelReale/fdep"
 	"github.com/RangelReale/fproto"
-	"github.com/gosimple/slug"
+	"github.com/RangelReale/fproto-doc"
 )
 
 type LayoutState int
@@ -19,20 +21,80 @@ const (
 )
 
 type Layout struct {
-	w   io.Writer
-	err error
+	w                 io.Writer
+	err               error
+	helper            *fproto_doc.Helper
+	options           *Options
+	commentPrecedence fproto_doc.CommentPrecedence
+	filter            *fproto_doc.GetFilter
+	links             []*fproto_doc.LinkMapping
+	sourceLink        *fproto_doc.SourceLink
+	sourceLines       map[string][]string // highlighted source lines by file path
 }
 
 func (l *Layout) Err() error {
 	return l.err
 }
 
-func (l *Layout) WriteHeader() {
+// Write a formatted string, keeping the first write error
+func (l *Layout) printf(format string, a ...interface{}) {
 	if l.err != nil {
 		return
 	}
+	_, l.err = fmt.Fprintf(l.w, format, a...)
+}
 
-	_, l.err = fmt.Fprint(l.w, layout_header)
+// Write a string, keeping the first write error
+func (l *Layout) print(s string) {
+	if l.err != nil {
+		return
+	}
+	_, l.err = io.WriteString(l.w, s)
+}
+
+func (l *Layout) WriteHeader(title string, styleSheet string) {
+	if l.err != nil {
+		return
+	}
+
+	page_title := title
+	if l.options.ProjectName != "" {
+		page_title = strings.TrimSpace(title + " - " + l.options.ProjectName + " " + l.options.Version)
+	}
+
+	l.printf(layout_head_begin, html.EscapeString(page_title))
+	l.print(layout_style)
+
+	if styleSheet != "" {
+		l.printf(`
+    <style type="text/css">
+%s
+    </style>
+`, styleSheet)
+	}
+
+	l.print(layout_body_begin)
+
+	l.print(`<header class="header">`)
+	if l.options.Logo != "" {
+		l.printf(`<img class="logo" src="%s" alt="%s"/>`, html.EscapeString(l.options.Logo), html.EscapeString(title))
+	}
+	if l.options.ProjectName != "" {
+		l.printf(`<span class="project-name">%s</span>`, html.EscapeString(l.options.ProjectName))
+	}
+	if l.options.Version != "" {
+		l.printf(`<span class="project-version">%s</span>`, html.EscapeString(l.options.Version))
+	}
+	if len(l.options.HeaderLinks) > 0 {
+		l.print(`<nav class="header-links">`)
+		for _, hl := range l.options.HeaderLinks {
+			l.printf(`<a href="%s">%s</a>`, html.EscapeString(hl.URL), html.EscapeString(hl.Title))
+		}
+		l.print(`</nav>`)
+	}
+	l.print(`</header>
+<div class="body">
+`)
 }
 
 func (l *Layout) WriteFooter() {
@@ -40,19 +102,47 @@ func (l *Layout) WriteFooter() {
 		return
 	}
 
-	_, l.err = fmt.Fprint(l.w, layout_footer)
+	l.print(`
+</div>
+<footer class="footer">`)
+	if l.options.FooterText != "" {
+		l.printf(`<p class="footer-text">%s</p>`, html.EscapeString(l.options.FooterText))
+	}
+	if l.options.FooterMarkdown != "" {
+		l.printf(`<div class="footer-text">%s</div>`, markdownToHTML(l.options.FooterMarkdown))
+	}
+	if l.options.FooterHTML != "" {
+		l.printf(`<div class="footer-text">%s</div>`, l.options.FooterHTML)
+	}
+
+	var meta []string
+	if !l.options.GeneratedAt.IsZero() {
+		meta = append(meta, fmt.Sprintf(`Generated at <time datetime="%s">%s</time>`,
+			l.options.GeneratedAt.Format(time.RFC3339), l.options.GeneratedAt.Format("2006-01-02 15:04:05 MST")))
+	}
+	if l.options.Commit != "" {
+		meta = append(meta, fmt.Sprintf(`Commit <code>%s</code>`, html.EscapeString(l.options.Commit)))
+	}
+	if len(meta) > 0 {
+		l.printf(`<p class="footer-meta">%s</p>`, strings.Join(meta, " &middot; "))
+	}
+
+	l.print(layout_footer)
 }
 
-func (l *Layout) WriteContent(layoutState LayoutState) {
+func (l *Layout) WriteContent(layoutState LayoutState, title string) {
 	if l.err != nil {
 		return
 	}
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprint(l.w, content_begin)
+		l.printf(content_begin, html.EscapeString(title))
+		if l.options.Subtitle != "" {
+			l.printf(`<p class="content-subtitle">%s</p>`, html.EscapeString(l.options.Subtitle))
+		}
 	case LS_END:
-		_, l.err = fmt.Fprint(l.w, content_end)
+		l.print(content_end)
 	}
 
 }
@@ -64,9 +154,9 @@ func (l *Layout) WriteContentItem(layoutState LayoutState, itemName string, link
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="item">
-            <a name="%s">%s</a>
+            <a id="%s">%s</a>
         </div>
 		`, link, itemName)
 	case LS_END:
@@ -80,36 +170,42 @@ func (l *Layout) WriteContentNs(layoutState LayoutState, nsName string, link str
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="ns">
-            <a name="%s">%s</a>
+            <a id="%s">%s</a>
         </div>
 		`, link, nsName)
 	case LS_END:
 	}
 }
 
-func (l *Layout) WriteContentNsItem(layoutState LayoutState, nsName string, link string, fileName string, pkg string) {
+func (l *Layout) WriteContentNsItem(layoutState LayoutState, nsName string, link string, fileName string, pkg string, sourceURL string) {
 	if l.err != nil {
 		return
 	}
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="ns-item">
-            <a name="%s">%s</a>
-		`, link, nsName)
+            <a id="%s">%s</a>%s
+		`, link, nsName, l.permalink(link))
 
-		if pkg != "" {
-			fmt.Fprintf(l.w, `<span class="pkg">[%s]</span>`, pkg)
+		if pkg != "" && l.options.ShowPackageBadge {
+			l.printf(`<span class="pkg">[%s]</span>`, pkg)
 		}
 
-		if fileName != "" {
-			fmt.Fprintf(l.w, `<span class="filename">[%s]</span>`, fileName)
+		if fileName != "" && l.options.ShowFileBadge {
+			if sourceURL != "" {
+				l.printf(`<span class="filename">[<a href="%s">%s</a>]</span>`, html.EscapeString(sourceURL), fileName)
+			} else {
+				l.printf(`<span class="filename">[%s]</span>`, fileName)
+			}
+		} else if sourceURL != "" {
+			l.print(l.sourceLinkIcon(sourceURL))
 		}
 
-		_, l.err = fmt.Fprint(l.w, `
+		l.print(`
         </div>`)
 
 	case LS_END:
@@ -123,9 +219,9 @@ func (l *Layout) WriteNav(layoutState LayoutState) {
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, nav_begin)
+		l.printf(nav_begin)
 	case LS_END:
-		_, l.err = fmt.Fprintf(l.w, nav_end)
+		l.printf(nav_end)
 	}
 }
 
@@ -136,7 +232,7 @@ func (l *Layout) WriteNavItem(layoutState LayoutState, itemName string, link str
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="item">
             <a href="#%s">%s</a>
         </div>
@@ -152,7 +248,7 @@ func (l *Layout) WriteNavNs(layoutState LayoutState, nsName string, link string)
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="ns">
             <a href="#%s">%s</a>
         </div>
@@ -168,7 +264,7 @@ func (l *Layout) WriteNavNsItem(layoutState LayoutState, nsName string, link str
 
 	switch layoutState {
 	case LS_BEGIN:
-		_, l.err = fmt.Fprintf(l.w, `
+		l.printf(`
         <div class="ns-item">
             <a href="#%s">%s</a>
         </div>
@@ -190,21 +286,33 @@ func (l *Layout) WriteContentService(dt *fdep.DepType) {
 
 	svc_comment := l.concatComment(element.Comment)
 
-	fmt.Fprint(l.w, `<div class="definition service">`)
+	l.print(`<div class="definition service">`)
 	if svc_comment != "" {
-		fmt.Fprint(l.w, `<div class="description"><p>`)
-		fmt.Fprintf(l.w, `%s`, svc_comment)
-		fmt.Fprint(l.w, `</p></div>`)
+		l.print(`<div class="description"><p>`)
+		l.printf(`%s`, svc_comment)
+		l.print(`</p></div>`)
+	}
+
+	rpcs := l.helper.OrderedRPCList(l.helper.FilterRPCList(dt, element.RPCs, l.filter.Exclude), l.options.FieldOrder)
+
+	// HTTP bindings, the column is only shown if any RPC has them
+	http_rules := make(map[*fproto.RPCElement][]*fproto_doc.HTTPRule)
+	http_header := ""
+	for _, rpc := range rpcs {
+		if rules := l.helper.GetHTTPRules(dt, rpc); len(rules) > 0 {
+			http_rules[rpc] = rules
+			http_header = "<th>HTTP</th>"
+		}
 	}
 
-	fmt.Fprint(l.w, `<div class="list">
+	l.printf(`<div class="list">
 		<table>
 			<tr>
-				<th>Method name</th><th>Request Type</th><th>Response Type</th><th>Description</th>
-			</tr>`)
+				<th>Method name</th><th>Request Type</th><th>Response Type</th>%s<th>Description</th>
+			</tr>`, http_header)
 
-	for _, rpc := range element.RPCs {
-		rpc_comment := l.concatComment(rpc.Comment)
+	for _, rpc := range rpcs {
+		rpc_comment := l.concatComment(l.itemComment(dt, rpc.Name, rpc.Comment))
 
 		// load field types
 		req_type, req_type_link, err := l.depTypeName(dt, rpc.RequestType)
@@ -220,23 +328,54 @@ func (l *Layout) WriteContentService(dt *fdep.DepType) {
 		}
 
 		if req_type_link != "" {
-			req_type = fmt.Sprintf(`<a href="#%s">%s</a>`, req_type_link, req_type)
+			req_type = fmt.Sprintf(`<a href="%s">%s</a>`, req_type_link, req_type)
 		}
 		if resp_type_link != "" {
-			resp_type = fmt.Sprintf(`<a href="#%s">%s</a>`, resp_type_link, resp_type)
+			resp_type = fmt.Sprintf(`<a href="%s">%s</a>`, resp_type_link, resp_type)
+		}
+
+		rpc_anchor := fproto_doc.MemberAnchor(fproto_doc.AK_RPC, dt, rpc.Name)
+		if l.options.ShowSource {
+			rpc_comment += l.sourceSnippet(dt, rpc.Name)
+		}
+		if l.options.ShowExamples {
+			for _, ex := range []struct{ typeName, summary string }{{rpc.RequestType, "Example request"}, {rpc.ResponseType, "Example response"}} {
+				ex_type, err := dt.FindType(ex.typeName)
+				if err != nil {
+					l.err = err
+					return
+				}
+				example, err := l.exampleSnippet(ex_type, ex.summary)
+				if err != nil {
+					l.err = err
+					return
+				}
+				rpc_comment += example
+			}
 		}
 
-		fmt.Fprintf(l.w, `
-		<tr>
-			<td class="fld-svc-method">%s</td>
+		rpc_http := ""
+		if http_header != "" {
+			rpc_http, err = l.httpRules(dt, rpc, http_rules[rpc])
+			if err != nil {
+				l.err = err
+				return
+			}
+			rpc_http = fmt.Sprintf(`<td class="fld-svc-http">%s</td>`, rpc_http)
+		}
+
+		l.printf(`
+		<tr id="%s">
+			<td class="fld-svc-method">%s%s</td>
 			<td class="fld-svc-req">%s</td>
 			<td  class="fld-svc-ret">%s</td>
+			%s
 			<td class="fld-svc-doc">%s</td>
 		</tr>`,
-			rpc.Name, req_type, resp_type, rpc_comment)
+			rpc_anchor, rpc.Name, l.permalink(rpc_anchor)+l.sourceLinkIcon(l.helper.SourceURL(l.sourceLink, dt, rpc.Name)), req_type, resp_type, rpc_http, rpc_comment)
 	}
 
-	_, l.err = fmt.Fprint(l.w, `</table>
+	l.print(`</table>
 	</div>
 	</div>`)
 }
@@ -254,32 +393,34 @@ func (l *Layout) WriteContentEnum(dt *fdep.DepType) {
 
 	en_comment := l.concatComment(element.Comment)
 
-	fmt.Fprint(l.w, `<div class="definition enum">`)
+	l.print(`<div class="definition enum">`)
 	if en_comment != "" {
-		fmt.Fprint(l.w, `<div class="description"><p>`)
-		fmt.Fprintf(l.w, `%s`, en_comment)
-		fmt.Fprint(l.w, `</p></div>`)
+		l.print(`<div class="description"><p>`)
+		l.printf(`%s`, en_comment)
+		l.print(`</p></div>`)
 	}
 
-	fmt.Fprint(l.w, `<div class="list">
+	l.print(`<div class="list">
 		<table>
 			<tr>
 				<th>Name</th><th>Value</th><th>Description</th>
 			</tr>`)
 
-	for _, ec := range element.EnumConstants {
-		ec_comment := l.concatComment(ec.Comment)
+	for _, ec := range l.helper.OrderedEnumConstantList(l.helper.FilterEnumConstantList(dt, element.EnumConstants, l.filter.Exclude), l.options.FieldOrder) {
+		ec_comment := l.concatComment(l.itemComment(dt, ec.Name, ec.Comment))
 
-		fmt.Fprintf(l.w, `
-		<tr>
-			<td class="fld-enum-name">%s</td>
+		ec_anchor := fproto_doc.MemberAnchor(fproto_doc.AK_ENUM_VALUE, dt, ec.Name)
+
+		l.printf(`
+		<tr id="%s">
+			<td class="fld-enum-name">%s%s</td>
 			<td class="fld-enum-value">%d</td>
 			<td  class="fld-enum-doc">%s</td>
 		</tr>`,
-			ec.Name, ec.Tag, ec_comment)
+			ec_anchor, ec.Name, l.permalink(ec_anchor)+l.sourceLinkIcon(l.helper.SourceURL(l.sourceLink, dt, ec.Name)), ec.Tag, ec_comment)
 	}
 
-	_, l.err = fmt.Fprint(l.w, `</table>
+	l.print(`</table>
 	</div>
 	</div>`)
 }
@@ -293,16 +434,25 @@ func (l *Layout) WriteContentMessage(dt *fdep.DepType) {
 
 	msg_comment := l.concatComment(element.Comment)
 
-	fmt.Fprint(l.w, `<div class="definition message">`)
+	l.print(`<div class="definition message">`)
 	if msg_comment != "" {
-		fmt.Fprint(l.w, `<div class="description"><p>`)
-		fmt.Fprintf(l.w, `%s`, msg_comment)
-		fmt.Fprint(l.w, `</p></div>`)
+		l.print(`<div class="description"><p>`)
+		l.printf(`%s`, msg_comment)
+		l.print(`</p></div>`)
 	}
 
 	l.writeFields(dt, element.Fields, "")
 
-	_, l.err = fmt.Fprint(l.w, `</div>`)
+	if l.options.ShowExamples && l.err == nil {
+		example, err := l.exampleSnippet(dt, "Example JSON")
+		if err != nil {
+			l.err = err
+			return
+		}
+		l.print(example)
+	}
+
+	l.print(`</div>`)
 }
 
 func (l *Layout) WriteContentOneofFields(dt *fdep.DepType, fields []fproto.FieldElementTag) {
@@ -313,23 +463,25 @@ func (l *Layout) WriteContentOneofFields(dt *fdep.DepType, fields []fproto.Field
 	for _, fld := range fields {
 		switch xfld := fld.(type) {
 		case *fproto.OneOfFieldElement:
-			fmt.Fprintf(l.w, `<div class="ns-itemsub">
-				<a name="%s">Oneof %s.%s</a>
-			</div>`, fmt.Sprintf("content-Oneof-%s-%s", slug.Make(dt.FullOriginalName()), slug.Make(xfld.Name)), dt.Name, xfld.Name)
+			oof_anchor := fproto_doc.MemberAnchor(fproto_doc.AK_ONEOF, dt, xfld.Name)
 
-			fmt.Fprint(l.w, `<div class="definition oneof">`)
+			l.printf(`<div class="ns-itemsub">
+				<a id="%s">Oneof %s.%s</a>%s
+			</div>`, oof_anchor, dt.Name, xfld.Name, l.permalink(oof_anchor))
+
+			l.print(`<div class="definition oneof">`)
 
 			oof_comment := l.concatComment(xfld.Comment)
 
 			if oof_comment != "" {
-				fmt.Fprint(l.w, `<div class="description"><p>`)
-				fmt.Fprintf(l.w, `%s`, oof_comment)
-				fmt.Fprint(l.w, `</p></div>`)
+				l.print(`<div class="description"><p>`)
+				l.printf(`%s`, oof_comment)
+				l.print(`</p></div>`)
 			}
 
 			l.writeFields(dt, xfld.Fields, "oneof")
 
-			fmt.Fprint(l.w, `</div>`)
+			l.print(`</div>`)
 		}
 	}
 }
@@ -343,21 +495,22 @@ func (l *Layout) writeFields(dt *fdep.DepType, fields []fproto.FieldElementTag,
 		tableClass = fmt.Sprintf(" class=\"%s\"", tableClass)
 	}
 
-	fmt.Fprintf(l.w, `<div class="list">
+	l.printf(`<div class="list">
 		<table%s>
 			<tr>
 				<th>Fieldname</th><th>Type</th><th>Flags</th><th>Description</th>
 			</tr>`, tableClass)
 
-	for _, fld := range fields {
+	for _, fld := range l.helper.OrderedFieldList(l.helper.FilterFieldList(dt, fields, l.filter.Exclude), l.options.FieldOrder) {
 		var fld_comment string
 		var fld_type string
 		var fld_type_link string
 		var fld_opt []string
+		var fld_rules []*fproto_doc.ValidationRule
 
 		switch xfld := fld.(type) {
 		case *fproto.FieldElement:
-			fld_comment = l.concatComment(xfld.Comment)
+			fld_comment = l.concatComment(l.itemComment(dt, fld.FieldName(), xfld.Comment))
 
 			// load field type
 			var err error
@@ -377,8 +530,9 @@ func (l *Layout) writeFields(dt *fdep.DepType, fields []fproto.FieldElementTag,
 			if xfld.Optional {
 				fld_opt = append(fld_opt, "optional")
 			}
+			fld_rules = l.helper.GetValidationRules(dt, xfld.Name, xfld.Options)
 		case *fproto.MapFieldElement:
-			fld_comment = l.concatComment(xfld.Comment)
+			fld_comment = l.concatComment(l.itemComment(dt, fld.FieldName(), xfld.Comment))
 
 			// load key and field type
 			f_key, f_key_link, err := l.depTypeName(dt, xfld.KeyType)
@@ -394,20 +548,21 @@ func (l *Layout) writeFields(dt *fdep.DepType, fields []fproto.FieldElementTag,
 
 			// build links
 			if f_key_link != "" {
-				f_key = fmt.Sprintf(`<a href="#%s">%s</a>`, f_key_link, f_key)
+				f_key = fmt.Sprintf(`<a href="%s">%s</a>`, f_key_link, f_key)
 			}
 			if f_value_link != "" {
-				f_value = fmt.Sprintf(`<a href="#%s">%s</a>`, f_value_link, f_value)
+				f_value = fmt.Sprintf(`<a href="%s">%s</a>`, f_value_link, f_value)
 			}
 
 			fld_type = fmt.Sprintf("map&lt;%s, %s&gt;", f_key, f_value)
+			fld_rules = l.helper.GetValidationRules(dt, xfld.Name, xfld.Options)
 		case *fproto.OneOfFieldElement:
 			fld_type = fmt.Sprint("oneof ")
-			fld_type_link = fmt.Sprintf("content-Oneof-%s-%s", slug.Make(dt.FullOriginalName()), slug.Make(xfld.Name))
-			fld_comment = l.concatComment(xfld.Comment)
+			fld_type_link = "#" + fproto_doc.MemberAnchor(fproto_doc.AK_ONEOF, dt, xfld.Name)
+			fld_comment = l.concatComment(l.itemComment(dt, fld.FieldName(), xfld.Comment))
 
 			var fextra []string
-			for _, oofld := range xfld.Fields {
+			for _, oofld := range l.helper.OrderedFieldList(l.helper.FilterFieldList(dt, xfld.Fields, l.filter.Exclude), l.options.FieldOrder) {
 				fextra = append(fextra, oofld.FieldName())
 			}
 
@@ -418,20 +573,35 @@ func (l *Layout) writeFields(dt *fdep.DepType, fields []fproto.FieldElementTag,
 
 		ftlink := fld_type
 		if fld_type_link != "" {
-			ftlink = fmt.Sprintf(`<a href="#%s">%s</a>`, fld_type_link, fld_type)
+			ftlink = fmt.Sprintf(`<a href="%s">%s</a>`, fld_type_link, fld_type)
 		}
+		lang_types, err := l.languageTypes(dt, fld)
+		if err != nil {
+			l.err = err
+			return
+		}
+		ftlink += lang_types
+
+		// oneofs are linked to their own section
+		fld_anchor := fproto_doc.MemberAnchor(fproto_doc.AK_FIELD, dt, fld.FieldName())
+		fld_name := fld.FieldName() + l.permalink(fld_anchor)
+		if _, is_oneof := fld.(*fproto.OneOfFieldElement); is_oneof {
+			fld_anchor = ""
+			fld_name = fld.FieldName()
+		}
+		fld_name += l.sourceLinkIcon(l.helper.SourceURL(l.sourceLink, dt, fld.FieldName()))
 
-		fmt.Fprintf(l.w, `
-			<tr>
+		l.printf(`
+			<tr%s>
 				<td class="fld-msg-fieldname">%s</td>
 				<td class="fld-msg-type">%s</td>
-				<td  class="fld-msg-opt">%s</td>
+				<td  class="fld-msg-opt">%s%s</td>
 				<td class="fld-msg-doc">%s</td>
 			</tr>`,
-			fld.FieldName(), ftlink, strings.Join(fld_opt, ","), fld_comment)
+			idAttr(fld_anchor), fld_name, ftlink, strings.Join(fld_opt, ","), l.validationRules(fld_rules, fld_opt), fld_comment)
 	}
 
-	_, l.err = fmt.Fprint(l.w, `</table>
+	l.print(`</table>
 	</div>`)
 }
 
@@ -452,21 +622,364 @@ func (l *Layout) depTypeName(parentType *fdep.DepType, typeName string) (ret_typ
 		} else {
 			ret_type_name = ft.Name
 		}
-		if !ft.IsScalar() && ft.DepFile.DepType == fdep.DepType_Own {
-			switch ft.Item.(type) {
-			case *fproto.EnumElement:
-				ret_type_link = fmt.Sprintf("content-Enum-%s", slug.Make(calc_type_name))
-			default:
-				ret_type_link = fmt.Sprintf("content-Message-%s", slug.Make(calc_type_name))
+		if !ft.IsScalar() && l.helper.IsIncludedType(ft, l.filter) {
+			ret_type_link = "#" + fproto_doc.TypeAnchor(ft)
+		} else if !ft.IsScalar() {
+			// external documentation
+			ret_type_link = html.EscapeString(fproto_doc.FindLinkURL(l.links, ft))
+			if wkt := fproto_doc.FindWellKnownType(calc_type_name); wkt != nil && (ret_type_link == "" || l.options.WellKnownTypes) {
+				ret_type_link = l.wellKnownTypeLink(wkt)
 			}
+		} else if l.options.ScalarTypes {
+			ret_type_link = "#" + fproto_doc.Anchor(fproto_doc.AK_SCALAR, typeName)
 		}
 	} else {
 		ret_type_name = typeName
+		if l.options.ScalarTypes && fproto_doc.FindScalarType(typeName) != nil {
+			ret_type_link = "#" + fproto_doc.Anchor(fproto_doc.AK_SCALAR, typeName)
+		}
+		// the well-known types may not be loaded
+		if wkt := fproto_doc.FindWellKnownType(typeName); wkt != nil {
+			ret_type_name = wkt.Name
+			ret_type_link = l.wellKnownTypeLink(wkt)
+		}
 	}
 
 	return
 }
 
+// HTTP bindings of a RPC, with the path variables and the body linked to the request fields
+func (l *Layout) httpRules(dt *fdep.DepType, rpc *fproto.RPCElement, rules []*fproto_doc.HTTPRule) (string, error) {
+	req_type, err := dt.FindType(rpc.RequestType)
+	if err != nil {
+		return "", err
+	}
+	resp_type, err := dt.FindType(rpc.ResponseType)
+	if err != nil {
+		return "", err
+	}
+
+	var ret []string
+	for _, rule := range rules {
+		// link the path variables
+		var path string
+		p := rule.Path
+		for {
+			start := strings.Index(p, "{")
+			end := strings.Index(p, "}")
+			if start < 0 || end < start {
+				break
+			}
+
+			v := p[start+1 : end]
+			field_path, pattern := v, ""
+			if pos := strings.Index(v, "="); pos >= 0 {
+				field_path, pattern = v[:pos], "="+v[pos+1:]
+			}
+			field_link, err := l.fieldPathLink(req_type, strings.TrimSpace(field_path))
+			if err != nil {
+				return "", err
+			}
+
+			path += html.EscapeString(p[:start]) + "{" + field_link + html.EscapeString(pattern) + "}"
+			p = p[end+1:]
+		}
+		path += html.EscapeString(p)
+
+		rule_html := fmt.Sprintf(`<span class="http-method http-%s">%s</span> <code class="http-path">%s</code>`,
+			strings.ToLower(rule.Method), html.EscapeString(rule.Method), path)
+
+		if rule.Body != "" {
+			body := "*"
+			if rule.Body != "*" {
+				if body, err = l.fieldPathLink(req_type, rule.Body); err != nil {
+					return "", err
+				}
+			}
+			rule_html += fmt.Sprintf(`<br/><span class="http-body">body: <code>%s</code></span>`, body)
+		}
+		if rule.ResponseBody != "" {
+			response_body, err := l.fieldPathLink(resp_type, rule.ResponseBody)
+			if err != nil {
+				return "", err
+			}
+			rule_html += fmt.Sprintf(`<br/><span class="http-body">response body: <code>%s</code></span>`, response_body)
+		}
+
+		ret = append(ret, `<div class="http-rule">`+rule_html+`</div>`)
+	}
+	return strings.Join(ret, ""), nil
+}
+
+// Field path linked to the field documentation, if the message is documented
+func (l *Layout) fieldPathLink(msg *fdep.DepType, fieldPath string) (string, error) {
+	if msg == nil {
+		return html.EscapeString(fieldPath), nil
+	}
+
+	fdt, fld, err := l.helper.FindFieldPath(msg, fieldPath)
+	if err != nil {
+		return "", err
+	}
+	if fld == nil || !l.helper.IsIncludedType(fdt, l.filter) {
+		return html.EscapeString(fieldPath), nil
+	}
+	return fmt.Sprintf(`<a href="#%s">%s</a>`, fproto_doc.MemberAnchor(fproto_doc.AK_FIELD, fdt, fld.Name), html.EscapeString(fieldPath)), nil
+}
+
+// Link to a well-known type, on the appendix if enabled or to its reference documentation
+func (l *Layout) wellKnownTypeLink(wkt *fproto_doc.WellKnownType) string {
+	if l.options.WellKnownTypes {
+		return "#" + fproto_doc.Anchor(fproto_doc.AK_WELL_KNOWN, wkt.Name)
+	}
+	return html.EscapeString(wkt.DocURL())
+}
+
+// Write the well-known types appendix
+func (l *Layout) WriteWellKnownTypes() {
+	if l.err != nil {
+		return
+	}
+
+	l.WriteContentItem(LS_BEGIN, "Well-known types", fproto_doc.SectionAnchor("wellknown", ""))
+
+	l.print(`<div class="definition"><div class="list">
+		<table>
+			<tr>
+				<th>Type</th><th>JSON</th><th>Description</th>
+			</tr>`)
+
+	for _, wkt := range fproto_doc.WellKnownTypes {
+		wkt_anchor := fproto_doc.Anchor(fproto_doc.AK_WELL_KNOWN, wkt.Name)
+
+		l.printf(`
+		<tr id="%s">
+			<td class="fld-wkt-name">%s%s</td>
+			<td class="fld-wkt-json">%s</td>
+			<td class="fld-wkt-doc">%s <a href="%s">Reference</a></td>
+		</tr>`,
+			wkt_anchor, wkt.Name, l.permalink(wkt_anchor), html.EscapeString(wkt.JSON), html.EscapeString(wkt.Description), html.EscapeString(wkt.DocURL()))
+	}
+
+	l.print(`</table>
+	</div></div>`)
+
+	l.WriteContentItem(LS_END, "Well-known types", "")
+}
+
+// Write the scalar value types appendix
+func (l *Layout) WriteScalarTypes() {
+	if l.err != nil {
+		return
+	}
+
+	l.WriteContentItem(LS_BEGIN, "Scalar value types", fproto_doc.SectionAnchor("scalars", ""))
+
+	l.print(`<div class="definition"><div class="list">
+		<table class="scalars">
+			<tr>
+				<th>.proto Type</th><th>Notes</th><th>Go</th><th>Java</th><th>Python</th><th>C++</th><th>C#</th><th>JavaScript</th><th>JSON</th>
+			</tr>`)
+
+	for _, st := range fproto_doc.ScalarTypes {
+		st_anchor := fproto_doc.Anchor(fproto_doc.AK_SCALAR, st.Name)
+
+		l.printf(`
+		<tr id="%s">
+			<td class="fld-scalar-name">%s%s</td>
+			<td class="fld-scalar-notes">%s</td>
+			<td>%s</td>
+			<td>%s</td>
+			<td>%s</td>
+			<td>%s</td>
+			<td>%s</td>
+			<td>%s</td>
+			<td>%s</td>
+		</tr>`,
+			st_anchor, st.Name, l.permalink(st_anchor), html.EscapeString(st.Notes),
+			html.EscapeString(st.Go), html.EscapeString(st.Java), html.EscapeString(st.Python), html.EscapeString(st.Cpp),
+			html.EscapeString(st.CSharp), html.EscapeString(st.JS), html.EscapeString(st.JSON))
+	}
+
+	l.print(`</table>
+	</div></div>`)
+
+	l.WriteContentItem(LS_END, "Scalar value types", "")
+}
+
+// Write the proto source of the definition
+func (l *Layout) WriteContentSource(dt *fdep.DepType) {
+	if l.err != nil || !l.options.ShowSource {
+		return
+	}
+
+	l.printf(`<div class="definition">%s</div>`, l.sourceSnippet(dt, ""))
+}
+
+// Write the highlighted proto source files, with line anchors
+func (l *Layout) WriteSourceFiles(files []string) {
+	if l.err != nil || len(files) == 0 {
+		return
+	}
+
+	l.WriteContentItem(LS_BEGIN, "Sources", fproto_doc.SectionAnchor("sources", ""))
+
+	for _, fn := range files {
+		lines := l.highlightedSource(fn)
+		if lines == nil {
+			continue
+		}
+
+		l.WriteContentNs(LS_BEGIN, fn, fproto_doc.SourceAnchor(fn, 0))
+		var buf bytes.Buffer
+		fmt.Fprint(&buf, `<div class="definition"><pre class="source">`)
+		for lidx, line := range lines {
+			// skip the empty line after the last line break
+			if lidx == len(lines)-1 && line == "" {
+				break
+			}
+			writeSourceLine(&buf, fn, lidx+1, line, true)
+		}
+		fmt.Fprint(&buf, `</pre></div>`)
+		l.print(buf.String())
+	}
+
+	l.WriteContentItem(LS_END, "Sources", "")
+}
+
+// Collapsible proto source of a definition, or of a declaration inside it if name isn't blank.
+// Returns a blank string if the source is not available.
+func (l *Layout) sourceSnippet(dt *fdep.DepType, name string) string {
+	decl := l.helper.GetSourceDecl(dt, name)
+	if decl == nil {
+		return ""
+	}
+	lines := l.highlightedSource(dt.DepFile.FilePath)
+	if decl.StartLine < 1 || decl.EndLine > len(lines) {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, `<details class="source"><summary>Source</summary><pre class="source">`)
+	for line := decl.StartLine; line <= decl.EndLine; line++ {
+		writeSourceLine(&buf, dt.DepFile.FilePath, line, lines[line-1], false)
+	}
+	fmt.Fprint(&buf, `</pre></details>`)
+
+	return buf.String()
+}
+
+// Collapsible example JSON of a message type.
+// Returns a blank string if the type is not a message.
+func (l *Layout) exampleSnippet(tp *fdep.DepType, summary string) (string, error) {
+	if tp == nil {
+		return "", nil
+	}
+	if _, ok := tp.Item.(*fproto.MessageElement); !ok {
+		return "", nil
+	}
+
+	example, err := l.helper.ExampleJSONText(tp, l.filter.Exclude, l.options.FieldOrder)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`<details class="example"><summary>%s</summary><pre class="example">%s</pre></details>`, summary, html.EscapeString(example)), nil
+}
+
+// Write a highlighted source line. The line number links to the line on the source files section.
+func writeSourceLine(w io.Writer, filePath string, line int, text string, withAnchor bool) {
+	anchor := fproto_doc.SourceAnchor(filePath, line)
+	id := ""
+	if withAnchor {
+		id = anchor
+	}
+	fmt.Fprintf(w, `<span class="line"%s><a class="ln" href="#%s">%d</a>%s</span>`, idAttr(id), anchor, line, text)
+}
+
+// Get the highlighted lines of a source file, or nil if the source is not available
+func (l *Layout) highlightedSource(filePath string) []string {
+	if lines, ok := l.sourceLines[filePath]; ok {
+		return lines
+	}
+	if l.sourceLines == nil {
+		l.sourceLines = make(map[string][]string)
+	}
+
+	var lines []string
+	if sf := l.helper.GetSourceFile(filePath); sf != nil {
+		lines = highlightProto(strings.Join(sf.Lines, "\n"))
+	}
+	l.sourceLines[filePath] = lines
+	return lines
+}
+
+// Generated type names and accessors of a field in the selected languages
+func (l *Layout) languageTypes(dt *fdep.DepType, fld fproto.FieldElementTag) (string, error) {
+	var buf bytes.Buffer
+	for _, lang := range l.options.Languages {
+		lf, err := l.helper.GetLanguageField(lang, dt, fld)
+		if err != nil {
+			return "", err
+		}
+		if lf == nil {
+			continue
+		}
+		fmt.Fprintf(&buf, `<div class="lang-type"><span class="lang">%s</span> <code>%s</code> <code>%s</code></div>`,
+			lang.Title(), html.EscapeString(lf.Type), html.EscapeString(lf.Accessor))
+	}
+	return buf.String(), nil
+}
+
+// List of the validation rules of a field, skipping the ones already in the flags
+func (l *Layout) validationRules(rules []*fproto_doc.ValidationRule, flags []string) string {
+	var items []string
+	for _, r := range rules {
+		text := r.Text()
+		if text == "" {
+			continue
+		}
+		dup := false
+		for _, f := range flags {
+			if f == text {
+				dup = true
+			}
+		}
+		if !dup {
+			items = append(items, "<li>"+html.EscapeString(text)+"</li>")
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return `<ul class="constraints">` + strings.Join(items, "") + `</ul>`
+}
+
+// Link to copy the permalink of the anchor
+func (l *Layout) permalink(anchor string) string {
+	return fmt.Sprintf(`<a class="permalink" href="#%s" title="Copy link">&#128279;</a>`, html.EscapeString(anchor))
+}
+
+// Link to the source repository, or a blank string if the URL is blank
+func (l *Layout) sourceLinkIcon(url string) string {
+	if url == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<a class="source-link" href="%s" title="View source">&lt;/&gt;</a>`, html.EscapeString(url))
+}
+
+// Id attribute, if the id isn't blank
+func idAttr(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf(` id="%s"`, html.EscapeString(id))
+}
+
+// Merge the leading comment with the trailing comment from the source, if available
+func (l *Layout) itemComment(dt *fdep.DepType, name string, comment *fproto.Comment) *fproto.Comment {
+	return l.helper.ItemComment(dt, name, comment, l.commentPrecedence)
+}
+
 func (l *Layout) concatComment(comment *fproto.Comment) string {
 	var ret string
 
@@ -500,12 +1013,15 @@ func (l *Layout) concatComment(comment *fproto.Comment) string {
 
 // layout strings
 var (
-	layout_header = `
+	layout_head_begin = `
 <!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
-    <title>Title</title>
+    <title>%s</title>
+`
+
+	layout_style = `
     <style type="text/css">
         /* RESET BEGIN */
         html, body, div, span, applet, object, iframe,
@@ -572,7 +1088,7 @@ var (
             color: #05a;
         }
 
-        a[name] {
+        .content a[id] {
             color: black;
         }
 
@@ -676,11 +1192,158 @@ var (
             padding: 1em;
         }
 
+        .header {
+            display: flex;
+            align-items: center;
+        }
+
+        .header .logo {
+            max-height: 50px;
+            margin: 5px 10px;
+        }
+
+        .header .project-name {
+            font-weight: bold;
+            font-size: 1.3em;
+            margin-left: 10px;
+        }
+
+        .header .project-version {
+            color: #808080;
+            margin-left: 8px;
+        }
+
+        .header .header-links {
+            margin-left: auto;
+            margin-right: 10px;
+        }
+
+        .header .header-links a {
+            margin-left: 15px;
+        }
+
+        .permalink, .permalink:visited {
+            color: #c0c0c0;
+            font-size: 0.8em;
+            margin-left: 6px;
+        }
+
+        .permalink:hover {
+            color: #05a;
+        }
+
+        .permalink.copied {
+            color: #2a2;
+        }
+
+        .source-link, .source-link:visited {
+            color: #c0c0c0;
+            font-size: 0.75em;
+            margin-left: 6px;
+        }
+
+        .source-link:hover {
+            color: #05a;
+        }
+
+        .body .content details.source {
+            margin: 4px 0 8px;
+        }
+
+        .body .content details.source summary {
+            cursor: pointer;
+            color: #05a;
+            font-size: 0.9em;
+        }
+
+        .body .content .lang-type {
+            font-size: 0.8em;
+            color: #555;
+            white-space: nowrap;
+        }
+
+        .body .content .lang-type .lang {
+            display: inline-block;
+            min-width: 5em;
+            color: #888;
+        }
+
+        .body .content ul.constraints {
+            margin: 0;
+            padding-left: 14px;
+            font-size: 0.9em;
+        }
+
+        .body .content details.example {
+            margin: 4px 0 8px;
+        }
+
+        .body .content details.example summary {
+            cursor: pointer;
+            color: #05a;
+            font-size: 0.9em;
+        }
+
+        .body .content pre.example {
+            font-family: Menlo, Consolas, "Courier New", monospace;
+            font-size: 0.85em;
+            line-height: 1.4em;
+            background-color: #f8f8f8;
+            border: solid 1px #e0e0e0;
+            padding: 4px 8px;
+            overflow-x: auto;
+            white-space: pre;
+        }
+
+        .body .content pre.source {
+            font-family: Menlo, Consolas, "Courier New", monospace;
+            font-size: 0.85em;
+            line-height: 1.4em;
+            background-color: #f8f8f8;
+            border: solid 1px #e0e0e0;
+            padding: 4px 0;
+            overflow-x: auto;
+            white-space: pre;
+        }
+
+        .body .content pre.source .line {
+            display: block;
+        }
+
+        .body .content pre.source .line:target {
+            background-color: #fff8c0;
+        }
+
+        .body .content pre.source .ln {
+            display: inline-block;
+            width: 3.5em;
+            padding-right: 1em;
+            text-align: right;
+            color: #a0a0a0;
+            user-select: none;
+        }
+
+        .hl-keyword { color: #00008b; font-weight: bold; }
+        .hl-type { color: #2b6f91; }
+        .hl-string { color: #a31515; }
+        .hl-number { color: #098658; }
+        .hl-comment { color: #008000; font-style: italic; }
+
         .footer{
             width: 100%;
             height: 60px;
         }
 
+        .footer .footer-text, .footer .footer-meta {
+            color: #808080;
+            padding: 0.5em 1em;
+        }
+
+        .body .content .content-subtitle {
+            color: #606060;
+            margin-bottom: 10px;
+        }
+
         @media (max-width: 700px) {
             .body {
                 flex-direction: column;
@@ -741,6 +1404,29 @@ var (
             width: 20%;
         }
 
+        .body .content .definition .list td.fld-svc-http .http-rule {
+            white-space: nowrap;
+            padding: 1px 0;
+        }
+
+        .body .content .definition .list td.fld-svc-http .http-method {
+            display: inline-block;
+            min-width: 3.5em;
+            font-weight: bold;
+            font-size: 0.85em;
+            color: #555555;
+        }
+
+        .http-get { color: #2a7ae2 !important; }
+        .http-post { color: #2a9d4a !important; }
+        .http-put, .http-patch { color: #c27c0e !important; }
+        .http-delete { color: #c2301d !important; }
+
+        .body .content .definition .list td.fld-svc-http .http-body {
+            color: #808080;
+            font-size: 0.85em;
+        }
+
         .body .content .definition .list td.fld-msg-fieldname {
             width: 15%;
         }
@@ -774,16 +1460,28 @@ var (
 
 
     </style>
+`
+
+	layout_body_begin = `
 </head>
 <body>
 
-<header class="header"></header>
-<div class="body">
 `
 
-	layout_footer = `
-</div>
-<footer class="footer"></footer>
+	layout_footer = `</footer>
+
+<script>
+    // copy the permalink to the clipboard
+    document.addEventListener("click", function(e) {
+        var a = e.target.closest ? e.target.closest("a.permalink") : null;
+        if (!a || !navigator.clipboard) {
+            return;
+        }
+        navigator.clipboard.writeText(location.href.split("#")[0] + a.getAttribute("href"));
+        a.classList.add("copied");
+        setTimeout(function() { a.classList.remove("copied"); }, 1000);
+    });
+</script>
 
 </body>
 </html>
@@ -791,7 +1489,7 @@ var (
 
 	content_begin = `
     <div class="content">
-        <h1 class="content-header">Documentation</h1>
+        <h1 class="content-header">%s</h1>
 `
 
 	content_end = `
@@ -0,0 +1,52 @@
+package fproto_doc_html_default
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	md_paragraph = regexp.MustCompile(`\n\s*\n`)
+	md_code      = regexp.MustCompile("`([^`]+)`")
+	md_link      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	md_strong    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	md_em        = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// Converts a small Markdown subset to HTML: paragraphs, line breaks, links, code, bold and italic
+func markdownToHTML(md string) string {
+	var paragraphs []string
+	for _, p := range md_paragraph.Split(strings.TrimSpace(md), -1) {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+
+		var lines []string
+		for _, ln := range strings.Split(p, "\n") {
+			lines = append(lines, markdownInline(strings.TrimSpace(ln)))
+		}
+		paragraphs = append(paragraphs, "<p>"+strings.Join(lines, "<br/>")+"</p>")
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+func markdownInline(text string) string {
+	text = html.EscapeString(text)
+
+	// keep code spans from being formatted
+	var codes []string
+	text = md_code.ReplaceAllStringFunc(text, func(s string) string {
+		codes = append(codes, "<code>"+md_code.FindStringSubmatch(s)[1]+"</code>")
+		return "\x00"
+	})
+
+	text = md_link.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = md_strong.ReplaceAllString(text, `<strong>$1</strong>`)
+	text = md_em.ReplaceAllString(text, `<em>$1</em>`)
+
+	for _, c := range codes {
+		text = strings.Replace(text, "\x00", c, 1)
+	}
+	return text
+}
@@ -0,0 +1,295 @@
+package fproto_doc_html_default
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RangelReale/fproto-doc"
+)
+
+// Sections of the page
+type Section int
+
+const (
+	SEC_NAV      Section = 1 << iota // Table of contents
+	SEC_SERVICES                     // Services
+	SEC_ENUMS                        // Enums
+	SEC_MESSAGES                     // Messages
+	SEC_ONEOFS                       // Oneof details of the messages
+
+	SEC_ALL Section = SEC_NAV | SEC_SERVICES | SEC_ENUMS | SEC_MESSAGES | SEC_ONEOFS // All sections
+)
+
+// Parse a list of section names (nav, services, enums, messages, oneofs), separated by commas.
+// The names only select the sections, they are always shown in the order of the constants.
+func ParseSections(names string) (Section, error) {
+	var ret Section
+	for _, sn := range strings.Split(names, ",") {
+		var sec Section
+		switch strings.TrimSpace(sn) {
+		case "nav":
+			sec = SEC_NAV
+		case "services":
+			sec = SEC_SERVICES
+		case "enums":
+			sec = SEC_ENUMS
+		case "messages":
+			sec = SEC_MESSAGES
+		case "oneofs":
+			sec = SEC_ONEOFS
+		case "all":
+			sec = SEC_ALL
+		case "":
+			continue
+		default:
+			return 0, fmt.Errorf("Unknown section: %s", sn)
+		}
+		if ret&sec != 0 {
+			return 0, fmt.Errorf("Duplicated section: %s", sn)
+		}
+		ret |= sec
+	}
+	return ret, nil
+}
+
+// Link shown on the page header
+type HeaderLink struct {
+	Title string
+	URL   string
+}
+
+// Parse a list of header links in the format "title=url", separated by commas
+func ParseHeaderLinks(links string) ([]*HeaderLink, error) {
+	var ret []*HeaderLink
+	for _, hl := range strings.Split(links, ",") {
+		hl = strings.TrimSpace(hl)
+		if hl == "" {
+			continue
+		}
+		pos := strings.Index(hl, "=")
+		if pos < 0 {
+			return nil, fmt.Errorf("Invalid header link, must be \"title=url\": %s", hl)
+		}
+		ret = append(ret, &HeaderLink{Title: strings.TrimSpace(hl[:pos]), URL: strings.TrimSpace(hl[pos+1:])})
+	}
+	return ret, nil
+}
+
+// HTML generator options
+type Options struct {
+	Title            string                   // page title, if blank the generator options title is used
+	Subtitle         string                   // text below the title
+	ProjectName      string                   // project name shown on the header
+	Version          string                   // project version shown on the header
+	Logo             string                   // logo image URL
+	HeaderLinks      []*HeaderLink            // links shown on the header
+	FooterText       string                   // footer text
+	FooterHTML       string                   // footer HTML, inserted as is
+	FooterMarkdown   string                   // footer Markdown
+	GeneratedAt      time.Time                // generation time shown on the footer, if not zero
+	Commit           string                   // source commit shown on the footer
+	Sections         Section                  // sections of the page
+	SortType         fproto_doc.SortType      // sort of the elements
+	FilterDepType    fproto_doc.FilterDepType // dependencies to document
+	ShowFileBadge    bool                     // show the file name of the elements
+	ShowPackageBadge bool                     // show the package of the elements
+	FieldOrder       fproto_doc.FieldOrder    // order of the message fields, enum constants and RPCs
+	ShowSource       bool                     // show the proto source of the definitions and the source files
+	ShowExamples     bool                     // show an example JSON of the messages and RPCs
+	WellKnownTypes   bool                     // show the well-known types appendix, and link the types to it
+	ScalarTypes      bool                     // show the scalar value types appendix, and link the types to it
+	Languages        []fproto_doc.Language    // languages to show the generated type names of the fields
+}
+
+func NewOptions() *Options {
+	return &Options{
+		Sections:         SEC_ALL,
+		SortType:         fproto_doc.ST_ALIAS_NAME,
+		FilterDepType:    fproto_doc.DT_OWN,
+		ShowFileBadge:    true,
+		ShowPackageBadge: true,
+		FieldOrder:       fproto_doc.FO_DECLARATION,
+	}
+}
+
+func (o *Options) SetTitle(title string, subtitle string) *Options {
+	o.Title = title
+	o.Subtitle = subtitle
+	return o
+}
+
+func (o *Options) SetLogo(logo string) *Options {
+	o.Logo = logo
+	return o
+}
+
+func (o *Options) SetProject(projectName string, version string) *Options {
+	o.ProjectName = projectName
+	o.Version = version
+	return o
+}
+
+func (o *Options) AddHeaderLink(title string, url string) *Options {
+	o.HeaderLinks = append(o.HeaderLinks, &HeaderLink{Title: title, URL: url})
+	return o
+}
+
+func (o *Options) SetFooterText(footerText string) *Options {
+	o.FooterText = footerText
+	return o
+}
+
+func (o *Options) SetFooterHTML(footerHTML string) *Options {
+	o.FooterHTML = footerHTML
+	return o
+}
+
+func (o *Options) SetFooterMarkdown(footerMarkdown string) *Options {
+	o.FooterMarkdown = footerMarkdown
+	return o
+}
+
+func (o *Options) SetGeneratedAt(generatedAt time.Time) *Options {
+	o.GeneratedAt = generatedAt
+	return o
+}
+
+func (o *Options) SetCommit(commit string) *Options {
+	o.Commit = commit
+	return o
+}
+
+func (o *Options) SetSections(sections Section) *Options {
+	o.Sections = sections
+	return o
+}
+
+func (o *Options) SetSort(sortType fproto_doc.SortType, filterDepType fproto_doc.FilterDepType) *Options {
+	o.SortType = sortType
+	o.FilterDepType = filterDepType
+	return o
+}
+
+func (o *Options) SetBadges(showFileBadge bool, showPackageBadge bool) *Options {
+	o.ShowFileBadge = showFileBadge
+	o.ShowPackageBadge = showPackageBadge
+	return o
+}
+
+func (o *Options) SetFieldOrder(fieldOrder fproto_doc.FieldOrder) *Options {
+	o.FieldOrder = fieldOrder
+	return o
+}
+
+func (o *Options) SetShowSource(showSource bool) *Options {
+	o.ShowSource = showSource
+	return o
+}
+
+func (o *Options) SetShowExamples(showExamples bool) *Options {
+	o.ShowExamples = showExamples
+	return o
+}
+
+func (o *Options) SetWellKnownTypes(wellKnownTypes bool) *Options {
+	o.WellKnownTypes = wellKnownTypes
+	return o
+}
+
+func (o *Options) SetScalarTypes(scalarTypes bool) *Options {
+	o.ScalarTypes = scalarTypes
+	return o
+}
+
+func (o *Options) SetLanguages(languages []fproto_doc.Language) *Options {
+	o.Languages = languages
+	return o
+}
+
+// Checks if the section is enabled
+func (o *Options) IsSectionEnabled(section Section) bool {
+	return o.Sections&section != 0
+}
+
+// Schema of the generator specific options
+var optionsInfo = []*fproto_doc.GeneratorOptionInfo{
+	{Name: "subtitle", Type: fproto_doc.GOT_STRING, Description: "Text below the title"},
+	{Name: "project_name", Type: fproto_doc.GOT_STRING, Description: "Project name shown on the header"},
+	{Name: "version", Type: fproto_doc.GOT_STRING, Description: "Project version shown on the header"},
+	{Name: "logo", Type: fproto_doc.GOT_STRING, Description: "Logo image URL"},
+	{Name: "header_links", Type: fproto_doc.GOT_STRING, Description: "Header links, as \"title=url\" separated by commas"},
+	{Name: "footer_text", Type: fproto_doc.GOT_STRING, Description: "Footer text"},
+	{Name: "footer_html", Type: fproto_doc.GOT_STRING, Description: "Footer HTML, inserted as is"},
+	{Name: "footer_markdown", Type: fproto_doc.GOT_STRING, Description: "Footer Markdown"},
+	{Name: "generated_at", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Show the generation time on the footer"},
+	{Name: "commit", Type: fproto_doc.GOT_STRING, Description: "Source commit shown on the footer, like the output of \"git rev-parse --short HEAD\" (default the commit of the source links)"},
+	{Name: "sections", Type: fproto_doc.GOT_STRING, Default: "all", Description: "Sections of the page: nav, services, enums, messages, oneofs. They are always shown in this order"},
+	{Name: "sort", Type: fproto_doc.GOT_STRING, Default: "alias_name", Description: "Sort of the elements: none, filepath_alias_name, alias_name, name"},
+	{Name: "dep_type", Type: fproto_doc.GOT_STRING, Default: "own", Description: "Dependencies to document: all, own, imported"},
+	{Name: "file_badge", Type: fproto_doc.GOT_BOOL, Default: "true", Description: "Show the file name of the elements"},
+	{Name: "package_badge", Type: fproto_doc.GOT_BOOL, Default: "true", Description: "Show the package of the elements"},
+	{Name: "source", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Show the proto source of the definitions and the source files, requires the source files"},
+	{Name: "examples", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Show an example JSON of the messages and of the RPC requests and responses"},
+	{Name: "well_known_types", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Show the well-known types appendix, and link the types to it instead of the reference documentation"},
+	{Name: "scalar_types", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Show the scalar value types appendix, and link the field types to it"},
+	{Name: "languages", Type: fproto_doc.GOT_STRING, Description: "Languages to show the generated type names and accessors of the fields: go, ts, java"},
+	{Name: "field_order", Type: fproto_doc.GOT_STRING, Description: "Order of the message fields, enum constants and RPCs: declaration, name, tag, RPCs ignore the tag order (default from the generator options)"},
+}
+
+// Creates the options from the generator specific option values, unset values keep the defaults
+func NewOptionsFromGeneratorOptions(options *fproto_doc.GeneratorOptions) (*Options, error) {
+	ret := NewOptions()
+	ret.Title = options.Title
+	ret.FieldOrder = options.FieldOrder
+	ret.Subtitle = options.Value("subtitle")
+	ret.ProjectName = options.Value("project_name")
+	ret.Version = options.Value("version")
+	ret.Logo = options.Value("logo")
+	ret.FooterText = options.Value("footer_text")
+	ret.FooterHTML = options.Value("footer_html")
+	ret.FooterMarkdown = options.Value("footer_markdown")
+	ret.Commit = options.Value("commit")
+	if ret.Commit == "" && options.SourceLink != nil {
+		ret.Commit = options.SourceLink.Commit
+	}
+	ret.ShowSource = options.BoolValue("source")
+	ret.ShowExamples = options.BoolValue("examples")
+	ret.WellKnownTypes = options.BoolValue("well_known_types")
+	ret.ScalarTypes = options.BoolValue("scalar_types")
+	if options.BoolValue("generated_at") {
+		ret.GeneratedAt = time.Now()
+	}
+	if _, ok := options.Values["file_badge"]; ok {
+		ret.ShowFileBadge = options.BoolValue("file_badge")
+	}
+	if _, ok := options.Values["package_badge"]; ok {
+		ret.ShowPackageBadge = options.BoolValue("package_badge")
+	}
+
+	var err error
+	if ret.HeaderLinks, err = ParseHeaderLinks(options.Value("header_links")); err != nil {
+		return nil, err
+	}
+	if v := options.Value("sections"); v != "" {
+		if ret.Sections, err = ParseSections(v); err != nil {
+			return nil, err
+		}
+	}
+	if ret.SortType, err = fproto_doc.ParseSortType(options.Value("sort")); err != nil {
+		return nil, err
+	}
+	if ret.FilterDepType, err = fproto_doc.ParseFilterDepType(options.Value("dep_type")); err != nil {
+		return nil, err
+	}
+	if ret.Languages, err = fproto_doc.ParseLanguages(options.Value("languages")); err != nil {
+		return nil, err
+	}
+	if v := options.Value("field_order"); v != "" {
+		if ret.FieldOrder, err = fproto_doc.ParseFieldOrder(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,45 @@
+package fproto_doc_json
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto-doc"
+)
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "json",
+		Description: "Documentation model as JSON",
+		FileName:    "doc.json",
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates the documentation model as JSON
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	helper := g.Options.NewHelper(dep)
+
+	model, err := helper.BuildModel(g.Options.Filter, g.Options.CommentPrecedence, g.Options.FieldOrder)
+	if err != nil {
+		return err
+	}
+	model.Title = g.Options.Title
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(model)
+}
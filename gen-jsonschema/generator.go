@@ -0,0 +1,377 @@
+package fproto_doc_jsonschema
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+	"github.com/RangelReale/fproto-doc"
+)
+
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "jsonschema",
+		Description: "JSON Schema (draft 2020-12) of the messages in the proto3 JSON mapping",
+		FileName:    "schema.json",
+		Options: []*fproto_doc.GeneratorOptionInfo{
+			{Name: "id", Type: fproto_doc.GOT_STRING, Description: "$id of the schema document"},
+			{Name: "strict", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Don't allow unknown properties in messages, the JSON and original field names are allowed"},
+			{Name: "message_files", Type: fproto_doc.GOT_BOOL, Default: "false", Description: "Also write a schema file per message, named \"<full name>.schema.json\", with the definitions it uses"},
+		},
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates a JSON Schema document with a definition for each message and enum.
+// Definitions are keyed by the full proto name, like "#/$defs/myorg.billing.Invoice".
+// The schema files of the messages reference their definition with a top-level $ref.
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	b, _, err := g.build(dep)
+	if err != nil {
+		return err
+	}
+	return writeSchema(w, b.doc)
+}
+
+// Generate the schema document, and with the message_files option a schema file per message
+func (g *Generator) GenerateFiles(dep *fdep.Dep, w io.Writer, create fproto_doc.FileCreator) error {
+	b, messages, err := g.build(dep)
+	if err != nil {
+		return err
+	}
+	if err := writeSchema(w, b.doc); err != nil {
+		return err
+	}
+	if !g.Options.BoolValue("message_files") {
+		return nil
+	}
+
+	for _, name := range messages {
+		fileName := name + ".schema.json"
+		doc := &Schema{
+			Schema: SchemaDialect,
+			Ref:    defRef(name).Ref,
+			Defs:   make(map[string]*Schema),
+		}
+		if id := b.doc.ID; id != "" {
+			// relative to the main document
+			doc.ID = id[:strings.LastIndex(id, "/")+1] + fileName
+		}
+		b.collectDefs(doc.Defs, name)
+
+		fw, err := create(fileName)
+		if err != nil {
+			return err
+		}
+		if err := writeSchema(fw, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build the schema document, returning also the full names of the documented messages
+func (g *Generator) build(dep *fdep.Dep) (*builder, []string, error) {
+	helper := g.Options.NewHelper(dep)
+
+	b := &builder{
+		helper:  helper,
+		options: g.Options,
+		strict:  g.Options.BoolValue("strict"),
+		doc: &Schema{
+			Schema: SchemaDialect,
+			ID:     g.Options.Value("id"),
+			Title:  g.Options.Title,
+			Defs:   make(map[string]*Schema),
+		},
+	}
+
+	filter := g.Options.Filter.With(fproto_doc.ST_ALIAS_NAME, g.Options.Filter.FilterDepType)
+	for _, dt := range helper.GetEnumList(filter) {
+		if err := b.addDefinition(dt); err != nil {
+			return nil, nil, err
+		}
+	}
+	var messages []string
+	for _, dt := range helper.GetMessageList(filter) {
+		if err := b.addDefinition(dt); err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, dt.FullOriginalName())
+	}
+	return b, messages, nil
+}
+
+func writeSchema(w io.Writer, schema *Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+type builder struct {
+	helper  *fproto_doc.Helper
+	options *fproto_doc.GeneratorOptions
+	strict  bool
+	doc     *Schema
+}
+
+// Get the reference to the definition of a type
+func defRef(name string) *Schema {
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// Copy the definition and the definitions it references to defs
+func (b *builder) collectDefs(defs map[string]*Schema, name string) {
+	if _, ok := defs[name]; ok {
+		return
+	}
+	def, ok := b.doc.Defs[name]
+	if !ok {
+		return
+	}
+	defs[name] = def
+
+	var walk func(s *Schema)
+	walk = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			b.collectDefs(defs, strings.TrimPrefix(s.Ref, "#/$defs/"))
+		}
+		walk(s.Items)
+		walk(s.PropertyNames)
+		walk(s.Not)
+		if ap, ok := s.AdditionalProperties.(*Schema); ok {
+			walk(ap)
+		}
+		for _, ps := range s.Properties {
+			walk(ps)
+		}
+		for _, list := range [][]*Schema{s.AnyOf, s.OneOf, s.AllOf} {
+			for _, ls := range list {
+				walk(ls)
+			}
+		}
+	}
+	walk(def)
+}
+
+// Adds the definition of a message or enum, and of the types it references
+func (b *builder) addDefinition(dt *fdep.DepType) error {
+	name := dt.FullOriginalName()
+	if _, ok := b.doc.Defs[name]; ok {
+		return nil
+	}
+
+	switch item := dt.Item.(type) {
+	case *fproto.EnumElement:
+		schema := &Schema{
+			Title:       name,
+			Type:        "string",
+			Description: fproto_doc.CommentText(item.Comment),
+		}
+		for _, ec := range b.helper.FilterEnumConstantList(dt, item.EnumConstants, b.options.Filter.Exclude) {
+			schema.Enum = append(schema.Enum, ec.Name)
+		}
+		b.doc.Defs[name] = schema
+	case *fproto.MessageElement:
+		schema := &Schema{
+			Title:       name,
+			Type:        "object",
+			Description: fproto_doc.CommentText(item.Comment),
+			Properties:  make(map[string]*Schema),
+		}
+		if b.strict {
+			schema.AdditionalProperties = false
+		}
+		// added before the fields for recursive types
+		b.doc.Defs[name] = schema
+		if err := b.addProperties(schema, dt, item.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) addProperties(schema *Schema, dt *fdep.DepType, fields []fproto.FieldElementTag) error {
+	for _, fld := range b.helper.FilterFieldList(dt, fields, b.options.Filter.Exclude) {
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			fschema, err := b.fieldSchema(dt, xfld)
+			if err != nil {
+				return err
+			}
+			b.addProperty(schema, xfld, fschema)
+		case *fproto.MapFieldElement:
+			fschema, err := b.mapSchema(dt, xfld)
+			if err != nil {
+				return err
+			}
+			b.addProperty(schema, xfld.FieldElement, fschema)
+		case *fproto.OneOfFieldElement:
+			if err := b.addProperties(schema, dt, xfld.Fields); err != nil {
+				return err
+			}
+			if oneof := b.oneOfSchema(dt, xfld); oneof != nil {
+				schema.AllOf = append(schema.AllOf, oneof)
+			}
+		}
+	}
+	return nil
+}
+
+// Adds the property of a field. In strict mode the field is also added with its
+// original name, which is also accepted by the ProtoJSON parsers.
+func (b *builder) addProperty(schema *Schema, fld *fproto.FieldElement, fschema *Schema) {
+	schema.Properties[fproto_doc.FieldJSONName(fld)] = fschema
+	if b.strict {
+		schema.Properties[fld.Name] = fschema
+	}
+}
+
+// Schema of a field, with its description
+func (b *builder) fieldSchema(dt *fdep.DepType, fld *fproto.FieldElement) (*Schema, error) {
+	schema, err := b.typeSchema(dt, fld.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if fld.Repeated {
+		schema = &Schema{Type: "array", Items: schema}
+	}
+
+	// in draft 2020-12 $ref may have siblings
+	schema.Description = fproto_doc.CommentText(b.helper.ItemComment(dt, fld.Name, fld.Comment, b.options.CommentPrecedence))
+	return schema, nil
+}
+
+// Schema of a map field, an object with the values as properties
+func (b *builder) mapSchema(dt *fdep.DepType, fld *fproto.MapFieldElement) (*Schema, error) {
+	vschema, err := b.typeSchema(dt, fld.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		Type:                 "object",
+		AdditionalProperties: vschema,
+		Description:          fproto_doc.CommentText(b.helper.ItemComment(dt, fld.Name, fld.Comment, b.options.CommentPrecedence)),
+	}
+
+	// keys are always strings in JSON
+	switch fld.KeyType {
+	case "bool":
+		schema.PropertyNames = &Schema{Enum: []interface{}{"true", "false"}}
+	case "string":
+	default:
+		schema.PropertyNames = &Schema{Pattern: "^-?[0-9]+$"}
+	}
+	return schema, nil
+}
+
+// Schema allowing at most one of the fields of a oneof to be set
+func (b *builder) oneOfSchema(dt *fdep.DepType, oneof *fproto.OneOfFieldElement) *Schema {
+	// schemas matching each field set
+	var set []*Schema
+	for _, fld := range b.helper.FilterFieldList(dt, oneof.Fields, b.options.Filter.Exclude) {
+		if xfld, ok := fld.(*fproto.FieldElement); ok {
+			json_name := fproto_doc.FieldJSONName(xfld)
+			if b.strict && json_name != xfld.Name {
+				set = append(set, &Schema{AnyOf: []*Schema{{Required: []string{json_name}}, {Required: []string{xfld.Name}}}})
+			} else {
+				set = append(set, &Schema{Required: []string{json_name}})
+			}
+		}
+	}
+	if len(set) < 2 {
+		return nil
+	}
+
+	ret := &Schema{}
+	none := &Schema{}
+	for _, fs := range set {
+		ret.OneOf = append(ret.OneOf, fs)
+		none.AnyOf = append(none.AnyOf, fs)
+	}
+	// none of the fields set
+	ret.OneOf = append(ret.OneOf, &Schema{Not: none})
+	return ret
+}
+
+// Schema of a type, adding the messages and enums to the definitions
+func (b *builder) typeSchema(parent *fdep.DepType, typeName string) (*Schema, error) {
+	if jt, ok := fproto_doc.ScalarJSONType(typeName); ok {
+		return jsonTypeSchema(jt), nil
+	}
+
+	ft, err := parent.FindType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if ft == nil {
+		return &Schema{}, nil
+	}
+
+	if jt, ok := fproto_doc.WellKnownJSONType(ft.FullOriginalName()); ok {
+		return jsonTypeSchema(jt), nil
+	}
+
+	switch ft.Item.(type) {
+	case *fproto.EnumElement, *fproto.MessageElement:
+		if err := b.addDefinition(ft); err != nil {
+			return nil, err
+		}
+		return defRef(ft.FullOriginalName()), nil
+	}
+	return &Schema{}, nil
+}
+
+// Schema of a JSON mapping type
+func jsonTypeSchema(jt fproto_doc.JSONType) *Schema {
+	schema := &Schema{}
+	switch jt.Type {
+	case "":
+		// any value
+		return schema
+	case "array":
+		schema.Items = &Schema{}
+	}
+
+	switch jt.Format {
+	case "int64":
+		// 64-bit integers are encoded as strings
+		schema.Pattern = "^-?[0-9]+$"
+	case "uint64":
+		schema.Pattern = "^[0-9]+$"
+	case "byte":
+		schema.ContentEncoding = "base64"
+	case "date-time":
+		schema.Format = jt.Format
+	case "duration":
+		schema.Pattern = `^-?[0-9]+(\.[0-9]{1,9})?s$`
+	}
+
+	if jt.Nullable {
+		schema.Type = []string{jt.Type, "null"}
+	} else {
+		schema.Type = jt.Type
+	}
+	return schema
+}
@@ -0,0 +1,181 @@
+package fproto_doc_markdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto-doc"
+)
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "markdown",
+		Description: "Markdown documentation",
+		FileName:    "README.md",
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates the documentation as Markdown
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	helper := g.Options.NewHelper(dep)
+
+	model, err := helper.BuildModel(g.Options.Filter, g.Options.CommentPrecedence, g.Options.FieldOrder)
+	if err != nil {
+		return err
+	}
+
+	mw := &mdWriter{w: w, types: make(map[string]fproto_doc.AnchorKind)}
+	for _, p := range model.Packages {
+		for _, e := range p.Enums {
+			mw.types[e.FullName] = fproto_doc.AK_ENUM
+		}
+		for _, m := range p.Messages {
+			mw.types[m.FullName] = fproto_doc.AK_MESSAGE
+		}
+	}
+
+	mw.printf("# %s\n\n", g.Options.Title)
+
+	// table of contents
+	mw.printf("## Table of Contents\n\n")
+	for _, p := range model.Packages {
+		mw.printf("- [%s](#%s)\n", p.Name, fproto_doc.Anchor(fproto_doc.AK_PACKAGE, p.Name))
+		for _, s := range p.Services {
+			mw.printf("  - [%s](#%s) (service)\n", s.Name, fproto_doc.Anchor(fproto_doc.AK_SERVICE, s.FullName))
+		}
+		for _, e := range p.Enums {
+			mw.printf("  - [%s](#%s) (enum)\n", e.Name, fproto_doc.Anchor(fproto_doc.AK_ENUM, e.FullName))
+		}
+		for _, m := range p.Messages {
+			mw.printf("  - [%s](#%s) (message)\n", m.Name, fproto_doc.Anchor(fproto_doc.AK_MESSAGE, m.FullName))
+		}
+	}
+	mw.printf("\n")
+
+	for _, p := range model.Packages {
+		mw.printf("<a name=\"%s\"></a>\n## %s\n\n", fproto_doc.Anchor(fproto_doc.AK_PACKAGE, p.Name), p.Name)
+
+		for _, s := range p.Services {
+			mw.writeService(s)
+		}
+		for _, e := range p.Enums {
+			mw.writeEnum(e)
+		}
+		for _, m := range p.Messages {
+			mw.writeMessage(m)
+		}
+	}
+
+	return mw.err
+}
+
+type mdWriter struct {
+	w     io.Writer
+	err   error
+	types map[string]fproto_doc.AnchorKind // kind of the documented types
+}
+
+func (mw *mdWriter) printf(format string, a ...interface{}) {
+	if mw.err != nil {
+		return
+	}
+	_, mw.err = fmt.Fprintf(mw.w, format, a...)
+}
+
+func (mw *mdWriter) writeHeader(kind fproto_doc.AnchorKind, name string, fullName string, file string, description string) {
+	mw.printf("<a name=\"%s\"></a>\n### %s %s\n\n", fproto_doc.Anchor(kind, fullName), strings.ToUpper(string(kind[:1]))+string(kind[1:]), name)
+	mw.printf("`%s` [%s]\n\n", fullName, file)
+	if description != "" {
+		mw.printf("%s\n\n", description)
+	}
+}
+
+func (mw *mdWriter) writeService(s *fproto_doc.ModelService) {
+	mw.writeHeader(fproto_doc.AK_SERVICE, s.Name, s.FullName, s.File, s.Description)
+
+	mw.printf("| Method name | Request Type | Response Type | Description |\n")
+	mw.printf("| ----------- | ------------ | ------------- | ----------- |\n")
+	for _, rpc := range s.RPCs {
+		req_type := mw.typeLink(rpc.RequestType, rpc.RequestFullType)
+		if rpc.RequestStreaming {
+			req_type = "stream " + req_type
+		}
+		resp_type := mw.typeLink(rpc.ResponseType, rpc.ResponseFullType)
+		if rpc.ResponseStreaming {
+			resp_type = "stream " + resp_type
+		}
+		mw.printf("| %s | %s | %s | %s |\n", rpc.Name, req_type, resp_type, cell(rpc.Description))
+	}
+	mw.printf("\n")
+}
+
+func (mw *mdWriter) writeEnum(e *fproto_doc.ModelEnum) {
+	mw.writeHeader(fproto_doc.AK_ENUM, e.Name, e.FullName, e.File, e.Description)
+
+	mw.printf("| Name | Value | Description |\n")
+	mw.printf("| ---- | ----- | ----------- |\n")
+	for _, v := range e.Values {
+		mw.printf("| %s | %d | %s |\n", v.Name, v.Number, cell(v.Description))
+	}
+	mw.printf("\n")
+}
+
+func (mw *mdWriter) writeMessage(m *fproto_doc.ModelMessage) {
+	mw.writeHeader(fproto_doc.AK_MESSAGE, m.Name, m.FullName, m.File, m.Description)
+
+	mw.printf("| Fieldname | Type | Flags | Description |\n")
+	mw.printf("| --------- | ---- | ----- | ----------- |\n")
+	for _, f := range m.Fields {
+		ftype := mw.typeLink(f.Type, f.FullType)
+		if f.KeyType != "" {
+			ftype = fmt.Sprintf("map&lt;%s, %s&gt;", f.KeyType, ftype)
+		}
+
+		var flags []string
+		if f.Label != "" {
+			flags = append(flags, f.Label)
+		}
+		if f.Oneof != "" {
+			flags = append(flags, "oneof "+f.Oneof)
+		}
+
+		mw.printf("| %s | %s | %s | %s |\n", f.Name, ftype, strings.Join(flags, ", "), cell(f.Description))
+	}
+	mw.printf("\n")
+
+	for _, o := range m.Oneofs {
+		mw.printf("**Oneof %s**: %s\n\n", o.Name, strings.Join(o.Fields, ", "))
+		if o.Description != "" {
+			mw.printf("%s\n\n", o.Description)
+		}
+	}
+}
+
+// Link the type if it is documented
+func (mw *mdWriter) typeLink(typeName string, fullType string) string {
+	if kind, ok := mw.types[fullType]; ok {
+		return fmt.Sprintf("[%s](#%s)", typeName, fproto_doc.Anchor(kind, fullType))
+	}
+	return typeName
+}
+
+// Escape text to be used inside a table cell
+func cell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	return strings.Replace(s, "\n", "<br/>", -1)
+}
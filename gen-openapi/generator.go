@@ -0,0 +1,426 @@
+package fproto_doc_openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+	"github.com/RangelReale/fproto-doc"
+)
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "openapi",
+		Description: "OpenAPI 3 document of the services with google.api.http bindings",
+		FileName:    "openapi.json",
+		Options: []*fproto_doc.GeneratorOptionInfo{
+			{Name: "version", Type: fproto_doc.GOT_STRING, Default: "1.0.0", Description: "API version of the document"},
+			{Name: "server", Type: fproto_doc.GOT_STRING, Description: "Server URL"},
+		},
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates an OpenAPI 3 document from the HTTP bindings of the RPCs
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	helper := g.Options.NewHelper(dep)
+
+	version := g.Options.Value("version")
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	b := &builder{
+		helper:  helper,
+		options: g.Options,
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    &Info{Title: g.Options.Title, Version: version},
+			Paths:   make(map[string]PathItem),
+			Components: &Components{
+				Schemas: make(map[string]*Schema),
+			},
+		},
+	}
+	if server := g.Options.Value("server"); server != "" {
+		b.doc.Servers = append(b.doc.Servers, &Server{URL: server})
+	}
+
+	filter := g.Options.Filter.With(fproto_doc.ST_ALIAS_NAME, g.Options.Filter.FilterDepType)
+	for _, dt := range helper.GetServiceList(filter) {
+		if err := b.addService(dt); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.doc)
+}
+
+type builder struct {
+	helper  *fproto_doc.Helper
+	options *fproto_doc.GeneratorOptions
+	doc     *Document
+}
+
+func (b *builder) addService(dt *fdep.DepType) error {
+	element := dt.Item.(*fproto.ServiceElement)
+
+	has_rules := false
+	for _, rpc := range b.helper.FilterRPCList(dt, element.RPCs, b.options.Filter.Exclude) {
+		for ridx, rule := range b.helper.GetHTTPRules(dt, rpc) {
+			method := strings.ToLower(rule.Method)
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options", "trace":
+			default:
+				// custom methods not supported by OpenAPI
+				continue
+			}
+
+			op, err := b.buildOperation(dt, rpc, rule)
+			if err != nil {
+				return fmt.Errorf("Error building operation of %s.%s: %v", dt.FullOriginalName(), rpc.Name, err)
+			}
+			// qualified, services of different packages may have the same name
+			op.OperationID = dt.FullOriginalName() + "_" + rpc.Name
+			if ridx > 0 {
+				op.OperationID += fmt.Sprint(ridx + 1)
+			}
+
+			path := openAPIPath(rule.Path)
+			if b.doc.Paths[path] == nil {
+				b.doc.Paths[path] = make(PathItem)
+			}
+			b.doc.Paths[path][method] = op
+			has_rules = true
+		}
+	}
+
+	if has_rules {
+		b.doc.Tags = append(b.doc.Tags, &Tag{
+			Name:        dt.FullOriginalName(),
+			Description: fproto_doc.CommentText(element.Comment),
+		})
+	}
+	return nil
+}
+
+func (b *builder) buildOperation(dt *fdep.DepType, rpc *fproto.RPCElement, rule *fproto_doc.HTTPRule) (*Operation, error) {
+	op := &Operation{
+		Tags:      []string{dt.FullOriginalName()},
+		Responses: make(map[string]*Response),
+	}
+	op.Summary, op.Description = splitComment(fproto_doc.CommentText(b.helper.ItemComment(dt, rpc.Name, rpc.Comment, b.options.CommentPrecedence)))
+
+	req_type, err := dt.FindType(rpc.RequestType)
+	if err != nil {
+		return nil, err
+	}
+	resp_type, err := dt.FindType(rpc.ResponseType)
+	if err != nil {
+		return nil, err
+	}
+
+	// path parameters
+	path_fields := make(map[string]bool)
+	for _, pv := range rule.PathVars() {
+		path_fields[pv.FieldPath] = true
+
+		param := &Parameter{Name: pv.FieldPath, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if req_type != nil {
+			fdt, fld, err := b.helper.FindFieldPath(req_type, pv.FieldPath)
+			if err != nil {
+				return nil, err
+			}
+			if fld != nil {
+				if param.Schema, err = b.fieldSchema(fdt, fld, false); err != nil {
+					return nil, err
+				}
+				param.Description = fproto_doc.CommentText(b.helper.ItemComment(fdt, fld.Name, fld.Comment, b.options.CommentPrecedence))
+			}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	// request body
+	if rule.Body != "" && req_type != nil {
+		var body_schema *Schema
+		if rule.Body == "*" {
+			if body_schema, err = b.typeSchema(dt, rpc.RequestType); err != nil {
+				return nil, err
+			}
+		} else {
+			fdt, fld, err := b.helper.FindFieldPath(req_type, rule.Body)
+			if err != nil {
+				return nil, err
+			}
+			if fld != nil {
+				if body_schema, err = b.fieldSchema(fdt, fld, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if body_schema != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{"application/json": {Schema: body_schema}},
+			}
+		}
+	}
+
+	// query parameters, the request fields not bound to the path or body
+	if rule.Body != "*" && req_type != nil {
+		if msg, ok := req_type.Item.(*fproto.MessageElement); ok {
+			visited := map[string]bool{req_type.FullOriginalName(): true}
+			if err := b.addQueryParameters(op, req_type, msg.Fields, path_fields, rule.Body, "", "", visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// response
+	resp := &Response{Description: "A successful response."}
+	if resp_type != nil {
+		var resp_schema *Schema
+		if rule.ResponseBody != "" {
+			fdt, fld, err := b.helper.FindFieldPath(resp_type, rule.ResponseBody)
+			if err != nil {
+				return nil, err
+			}
+			if fld != nil {
+				if resp_schema, err = b.fieldSchema(fdt, fld, false); err != nil {
+					return nil, err
+				}
+			}
+		} else if resp_schema, err = b.typeSchema(dt, rpc.ResponseType); err != nil {
+			return nil, err
+		}
+		if resp_schema != nil {
+			resp.Content = map[string]*MediaType{"application/json": {Schema: resp_schema}}
+		}
+	}
+	op.Responses["200"] = resp
+	op.Responses["default"] = &Response{Description: "An unexpected error response."}
+
+	return op, nil
+}
+
+// Maximum depth of the message fields flattened as query parameters
+const queryParameterMaxDepth = 5
+
+// Adds the scalar and enum fields as query parameters. Non-repeated message fields
+// are flattened as "parent.child" parameters, the visited messages are skipped to
+// avoid recursion.
+func (b *builder) addQueryParameters(op *Operation, dt *fdep.DepType, fields []fproto.FieldElementTag, path_fields map[string]bool, body string, prefix string, json_prefix string, visited map[string]bool) error {
+	for _, fld := range b.helper.FilterFieldList(dt, fields, b.options.Filter.Exclude) {
+		var xfld *fproto.FieldElement
+		switch f := fld.(type) {
+		case *fproto.FieldElement:
+			xfld = f
+		case *fproto.OneOfFieldElement:
+			if err := b.addQueryParameters(op, dt, f.Fields, path_fields, body, prefix, json_prefix, visited); err != nil {
+				return err
+			}
+			continue
+		default:
+			// maps can't be query parameters
+			continue
+		}
+
+		field_path := prefix + xfld.Name
+		json_path := json_prefix + fproto_doc.FieldJSONName(xfld)
+		if path_fields[field_path] || field_path == body {
+			continue
+		}
+
+		ft, err := dt.FindType(xfld.Type)
+		if err != nil {
+			return err
+		}
+		if ft != nil && !ft.IsScalar() {
+			if _, is_enum := ft.Item.(*fproto.EnumElement); !is_enum {
+				if jt, ok := fproto_doc.WellKnownJSONType(ft.FullOriginalName()); ok {
+					if jt.Type == "" || jt.Type == "object" || jt.Type == "array" {
+						continue
+					}
+				} else {
+					msg, is_msg := ft.Item.(*fproto.MessageElement)
+					if is_msg && !xfld.Repeated && len(visited) < queryParameterMaxDepth && !visited[ft.FullOriginalName()] {
+						visited[ft.FullOriginalName()] = true
+						err := b.addQueryParameters(op, ft, msg.Fields, path_fields, body, field_path+".", json_path+".", visited)
+						delete(visited, ft.FullOriginalName())
+						if err != nil {
+							return err
+						}
+					}
+					continue
+				}
+			}
+		}
+
+		schema, err := b.fieldSchema(dt, xfld, false)
+		if err != nil {
+			return err
+		}
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        json_path,
+			In:          "query",
+			Description: fproto_doc.CommentText(b.helper.ItemComment(dt, xfld.Name, xfld.Comment, b.options.CommentPrecedence)),
+			Schema:      schema,
+		})
+	}
+	return nil
+}
+
+// Schema of a field, with the description if withDescription is true
+func (b *builder) fieldSchema(dt *fdep.DepType, fld *fproto.FieldElement, withDescription bool) (*Schema, error) {
+	schema, err := b.typeSchema(dt, fld.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if fld.Repeated {
+		schema = &Schema{Type: "array", Items: schema}
+	}
+
+	if withDescription {
+		description := fproto_doc.CommentText(b.helper.ItemComment(dt, fld.Name, fld.Comment, b.options.CommentPrecedence))
+		if schema.Ref != "" && description != "" {
+			// siblings of $ref are ignored
+			schema = &Schema{AllOf: []*Schema{schema}}
+		}
+		schema.Description = description
+	}
+
+	return schema, nil
+}
+
+// Schema of a type, adding the messages and enums to the components
+func (b *builder) typeSchema(parent *fdep.DepType, typeName string) (*Schema, error) {
+	if jt, ok := fproto_doc.ScalarJSONType(typeName); ok {
+		return &Schema{Type: jt.Type, Format: jt.Format}, nil
+	}
+
+	ft, err := parent.FindType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	if ft == nil {
+		return &Schema{}, nil
+	}
+
+	if jt, ok := fproto_doc.WellKnownJSONType(ft.FullOriginalName()); ok {
+		switch jt.Type {
+		case "":
+			return &Schema{}, nil
+		case "null":
+			// OpenAPI 3.0 has no null type
+			return &Schema{Type: "string", Nullable: true, Enum: []interface{}{nil}}, nil
+		case "array":
+			return &Schema{Type: "array", Items: &Schema{}}, nil
+		}
+		return &Schema{Type: jt.Type, Format: jt.Format, Nullable: jt.Nullable}, nil
+	}
+
+	name := ft.FullOriginalName()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := b.doc.Components.Schemas[name]; ok {
+		return ref, nil
+	}
+
+	switch item := ft.Item.(type) {
+	case *fproto.EnumElement:
+		schema := &Schema{Type: "string", Description: fproto_doc.CommentText(item.Comment)}
+		for _, ec := range b.helper.FilterEnumConstantList(ft, item.EnumConstants, b.options.Filter.Exclude) {
+			schema.Enum = append(schema.Enum, ec.Name)
+		}
+		b.doc.Components.Schemas[name] = schema
+	case *fproto.MessageElement:
+		schema := &Schema{Type: "object", Description: fproto_doc.CommentText(item.Comment), Properties: make(map[string]*Schema)}
+		// added before the fields for recursive types
+		b.doc.Components.Schemas[name] = schema
+		if err := b.addProperties(schema, ft, item.Fields); err != nil {
+			return nil, err
+		}
+	default:
+		return &Schema{}, nil
+	}
+
+	return ref, nil
+}
+
+func (b *builder) addProperties(schema *Schema, dt *fdep.DepType, fields []fproto.FieldElementTag) error {
+	for _, fld := range b.helper.FilterFieldList(dt, fields, b.options.Filter.Exclude) {
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			fschema, err := b.fieldSchema(dt, xfld, true)
+			if err != nil {
+				return err
+			}
+			schema.Properties[fproto_doc.FieldJSONName(xfld)] = fschema
+		case *fproto.MapFieldElement:
+			vschema, err := b.typeSchema(dt, xfld.Type)
+			if err != nil {
+				return err
+			}
+			schema.Properties[fproto_doc.FieldJSONName(xfld.FieldElement)] = &Schema{
+				Type:                 "object",
+				AdditionalProperties: vschema,
+				Description:          fproto_doc.CommentText(b.helper.ItemComment(dt, xfld.Name, xfld.Comment, b.options.CommentPrecedence)),
+			}
+		case *fproto.OneOfFieldElement:
+			// only one of the fields may be set, OpenAPI 3.0 can't express it on properties
+			if err := b.addProperties(schema, dt, xfld.Fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Converts a path template to an OpenAPI path, removing the variable patterns
+func openAPIPath(path string) string {
+	var ret strings.Builder
+	for {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			break
+		}
+
+		v := path[start+1 : end]
+		if pos := strings.Index(v, "="); pos >= 0 {
+			v = v[:pos]
+		}
+		ret.WriteString(path[:start] + "{" + strings.TrimSpace(v) + "}")
+		path = path[end+1:]
+	}
+	ret.WriteString(path)
+	return ret.String()
+}
+
+// Split a comment in summary, the first line, and description, the rest
+func splitComment(comment string) (summary string, description string) {
+	parts := strings.SplitN(comment, "\n", 2)
+	summary = strings.TrimSpace(parts[0])
+	if len(parts) > 1 {
+		description = strings.TrimSpace(parts[1])
+	}
+	return
+}
@@ -0,0 +1,305 @@
+package fproto_doc_rst
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto-doc"
+)
+
+func init() {
+	fproto_doc.RegisterGenerator(&fproto_doc.GeneratorInfo{
+		Name:        "rst",
+		Description: "reStructuredText documentation for Sphinx, with a document per package",
+		FileName:    "index.rst",
+		Factory: func(options *fproto_doc.GeneratorOptions) (fproto_doc.Generator, error) {
+			return &Generator{Options: options}, nil
+		},
+	})
+}
+
+// Generates the documentation as reStructuredText for Sphinx.
+// Types and RPCs are cross-referenced with ":ref:" using the anchor IDs as labels, and all
+// services, RPCs, enums and messages have index entries. Each package has a local table of contents.
+type Generator struct {
+	Options *fproto_doc.GeneratorOptions
+}
+
+func NewGenerator() *Generator {
+	return &Generator{
+		Options: fproto_doc.NewGeneratorOptions(),
+	}
+}
+
+// Generate all the packages in a single document
+func (g *Generator) Generate(dep *fdep.Dep, w io.Writer) error {
+	model, err := g.buildModel(dep)
+	if err != nil {
+		return err
+	}
+
+	rw := newRSTWriter(w, model)
+	rw.title(g.Options.Title, "=", true)
+	for _, p := range model.Packages {
+		rw.writePackage(p, "-", "~", "^")
+	}
+	return rw.err
+}
+
+// Generate the index with a toctree, and a document per package
+func (g *Generator) GenerateFiles(dep *fdep.Dep, w io.Writer, create fproto_doc.FileCreator) error {
+	model, err := g.buildModel(dep)
+	if err != nil {
+		return err
+	}
+
+	rw := newRSTWriter(w, model)
+	rw.title(g.Options.Title, "=", true)
+	rw.printf(".. toctree::\n   :maxdepth: 2\n\n")
+	for _, p := range model.Packages {
+		rw.printf("   %s\n", documentName(p))
+	}
+	rw.printf("\n")
+	if rw.err != nil {
+		return rw.err
+	}
+
+	for _, p := range model.Packages {
+		pw, err := create(documentName(p) + ".rst")
+		if err != nil {
+			return err
+		}
+
+		prw := newRSTWriter(pw, model)
+		prw.writePackage(p, "=", "-", "~")
+		if prw.err != nil {
+			return prw.err
+		}
+	}
+	return nil
+}
+
+// Name of the document of a package, files without a package are in "default"
+func documentName(p *fproto_doc.ModelPackage) string {
+	if p.Name == "" {
+		return "default"
+	}
+	return p.Name
+}
+
+func (g *Generator) buildModel(dep *fdep.Dep) (*fproto_doc.Model, error) {
+	helper := g.Options.NewHelper(dep)
+	return helper.BuildModel(g.Options.Filter, g.Options.CommentPrecedence, g.Options.FieldOrder)
+}
+
+type rstWriter struct {
+	w     io.Writer
+	err   error
+	types map[string]fproto_doc.AnchorKind // kind of the documented types
+}
+
+func newRSTWriter(w io.Writer, model *fproto_doc.Model) *rstWriter {
+	rw := &rstWriter{w: w, types: make(map[string]fproto_doc.AnchorKind)}
+	for _, p := range model.Packages {
+		for _, e := range p.Enums {
+			rw.types[e.FullName] = fproto_doc.AK_ENUM
+		}
+		for _, m := range p.Messages {
+			rw.types[m.FullName] = fproto_doc.AK_MESSAGE
+		}
+	}
+	return rw
+}
+
+func (rw *rstWriter) printf(format string, a ...interface{}) {
+	if rw.err != nil {
+		return
+	}
+	_, rw.err = fmt.Fprintf(rw.w, format, a...)
+}
+
+// Write a section title with the adornment character, and optionally an overline
+func (rw *rstWriter) title(title string, adornment string, overline bool) {
+	line := strings.Repeat(adornment, len(title))
+	if overline {
+		rw.printf("%s\n", line)
+	}
+	rw.printf("%s\n%s\n\n", title, line)
+}
+
+// Write a label for the ":ref:" cross references
+func (rw *rstWriter) label(anchor string) {
+	rw.printf(".. _%s:\n\n", anchor)
+}
+
+// Write the index entries
+func (rw *rstWriter) index(entries ...string) {
+	rw.printf(".. index::\n")
+	for _, e := range entries {
+		rw.printf("   single: %s\n", e)
+	}
+	rw.printf("\n")
+}
+
+// Write a package, with the adornments of the package, type and oneof titles.
+// Files without a package have no package title, their types are written on its level.
+func (rw *rstWriter) writePackage(p *fproto_doc.ModelPackage, pkgAdornment string, typeAdornment string, oneofAdornment string) {
+	if p.Name != "" {
+		rw.label(fproto_doc.Anchor(fproto_doc.AK_PACKAGE, p.Name))
+		rw.title(p.Name, pkgAdornment, false)
+		rw.printf(".. contents::\n   :local:\n   :depth: 1\n\n")
+	} else {
+		typeAdornment, oneofAdornment = pkgAdornment, typeAdornment
+	}
+
+	for _, s := range p.Services {
+		rw.writeService(s, typeAdornment, oneofAdornment)
+	}
+	for _, e := range p.Enums {
+		rw.writeEnum(e, typeAdornment)
+	}
+	for _, m := range p.Messages {
+		rw.writeMessage(m, typeAdornment, oneofAdornment)
+	}
+}
+
+func (rw *rstWriter) writeHeader(kind fproto_doc.AnchorKind, name string, fullName string, file string, description string, adornment string) {
+	rw.label(fproto_doc.Anchor(kind, fullName))
+	rw.title(strings.ToUpper(string(kind[:1]))+string(kind[1:])+" "+name, adornment, false)
+	rw.printf("``%s`` [%s]\n\n", fullName, text(file))
+	if description != "" {
+		rw.printf("%s\n\n", text(description))
+	}
+}
+
+// Write the header of a list table
+func (rw *rstWriter) tableHeader(widths string, titles ...string) {
+	rw.printf(".. list-table::\n   :header-rows: 1\n   :widths: %s\n\n", widths)
+	rw.tableRow(titles...)
+}
+
+// Write a row of a list table
+func (rw *rstWriter) tableRow(cells ...string) {
+	for cidx, c := range cells {
+		prefix := "     -"
+		if cidx == 0 {
+			prefix = "   * -"
+		}
+		if c == "" {
+			rw.printf("%s\n", prefix)
+			continue
+		}
+		rw.printf("%s %s\n", prefix, strings.Replace(c, "\n", "\n       ", -1))
+	}
+}
+
+func (rw *rstWriter) writeService(s *fproto_doc.ModelService, adornment string, rpcAdornment string) {
+	rw.index(s.FullName + " (service)")
+
+	rw.writeHeader(fproto_doc.AK_SERVICE, s.Name, s.FullName, s.File, s.Description, adornment)
+
+	// the descriptions are in the RPC sections
+	rw.tableHeader("30 35 35", "Method name", "Request Type", "Response Type")
+	for _, rpc := range s.RPCs {
+		req_type := rw.rpcTypeLink(rpc.RequestType, rpc.RequestFullType, rpc.RequestStreaming)
+		resp_type := rw.rpcTypeLink(rpc.ResponseType, rpc.ResponseFullType, rpc.ResponseStreaming)
+		rw.tableRow(fmt.Sprintf(":ref:`%s <%s>`", rpc.Name, fproto_doc.Anchor(fproto_doc.AK_RPC, s.FullName+"."+rpc.Name)), req_type, resp_type)
+	}
+	rw.printf("\n")
+
+	for _, rpc := range s.RPCs {
+		rw.index(s.FullName + "." + rpc.Name + " (rpc)")
+		rw.label(fproto_doc.Anchor(fproto_doc.AK_RPC, s.FullName+"."+rpc.Name))
+		rw.title("RPC "+s.Name+"."+rpc.Name, rpcAdornment, false)
+		rw.printf("Request: %s, response: %s\n\n", rw.rpcTypeLink(rpc.RequestType, rpc.RequestFullType, rpc.RequestStreaming),
+			rw.rpcTypeLink(rpc.ResponseType, rpc.ResponseFullType, rpc.ResponseStreaming))
+		if rpc.Description != "" {
+			rw.printf("%s\n\n", text(rpc.Description))
+		}
+	}
+}
+
+// Cross reference to a request or response type, with the streaming flag
+func (rw *rstWriter) rpcTypeLink(typeName string, fullType string, streaming bool) string {
+	if streaming {
+		return "stream " + rw.typeLink(typeName, fullType)
+	}
+	return rw.typeLink(typeName, fullType)
+}
+
+func (rw *rstWriter) writeEnum(e *fproto_doc.ModelEnum, adornment string) {
+	rw.index(e.FullName + " (enum)")
+	rw.writeHeader(fproto_doc.AK_ENUM, e.Name, e.FullName, e.File, e.Description, adornment)
+
+	rw.tableHeader("30 10 60", "Name", "Value", "Description")
+	for _, v := range e.Values {
+		rw.tableRow(text(v.Name), fmt.Sprint(v.Number), text(v.Description))
+	}
+	rw.printf("\n")
+}
+
+func (rw *rstWriter) writeMessage(m *fproto_doc.ModelMessage, adornment string, oneofAdornment string) {
+	rw.index(m.FullName + " (message)")
+	rw.writeHeader(fproto_doc.AK_MESSAGE, m.Name, m.FullName, m.File, m.Description, adornment)
+
+	rw.tableHeader("20 20 20 40", "Fieldname", "Type", "Flags", "Description")
+	for _, f := range m.Fields {
+		ftype := rw.typeLink(f.Type, f.FullType)
+		if f.KeyType != "" {
+			ftype = fmt.Sprintf("map<%s, %s>", text(f.KeyType), ftype)
+		}
+
+		var flags []string
+		if f.Label != "" {
+			flags = append(flags, f.Label)
+		}
+		if f.Oneof != "" {
+			flags = append(flags, fmt.Sprintf(":ref:`oneof %s <%s>`", f.Oneof, fproto_doc.Anchor(fproto_doc.AK_ONEOF, m.FullName+"."+f.Oneof)))
+		}
+		for _, v := range f.Validation {
+			if v.Text != "" {
+				flags = append(flags, text(v.Text))
+			}
+		}
+
+		rw.tableRow(text(f.Name), ftype, strings.Join(flags, ", "), text(f.Description))
+	}
+	rw.printf("\n")
+
+	for _, o := range m.Oneofs {
+		rw.label(fproto_doc.Anchor(fproto_doc.AK_ONEOF, m.FullName+"."+o.Name))
+		rw.title("Oneof "+m.Name+"."+o.Name, oneofAdornment, false)
+		if o.Description != "" {
+			rw.printf("%s\n\n", text(o.Description))
+		}
+
+		var fields []string
+		for _, f := range o.Fields {
+			fields = append(fields, "``"+f+"``")
+		}
+		rw.printf("Only one of the fields may be set: %s\n\n", strings.Join(fields, ", "))
+	}
+}
+
+// Cross reference to the type if it is documented
+func (rw *rstWriter) typeLink(typeName string, fullType string) string {
+	if kind, ok := rw.types[fullType]; ok {
+		return fmt.Sprintf(":ref:`%s <%s>`", typeName, fproto_doc.Anchor(kind, fullType))
+	}
+	return text(typeName)
+}
+
+// Escape the inline markup characters of a text
+var textReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"*", "\\*",
+	"`", "\\`",
+	"|", "\\|",
+	"_", "\\_",
+)
+
+func text(s string) string {
+	return textReplacer.Replace(s)
+}
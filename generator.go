@@ -1,7 +1,10 @@
 package fproto_doc
 
 import (
+	"fmt"
 	"io"
+	"sort"
+	"strconv"
 
 	"github.com/RangelReale/fdep"
 )
@@ -9,3 +12,149 @@ import (
 type Generator interface {
 	Generate(fdep *fdep.Dep, w io.Writer) error
 }
+
+// Generator that can also write other files besides the main one, like a file per package.
+// The names of the other files are relative to the directory of the main file.
+// Generate is used when only one file can be written.
+type MultiFileGenerator interface {
+	Generator
+	GenerateFiles(fdep *fdep.Dep, w io.Writer, create FileCreator) error
+}
+
+// Creates an additional file of a generator
+type FileCreator func(fileName string) (io.Writer, error)
+
+// Options common to all generators
+type GeneratorOptions struct {
+	Title             string            // documentation title
+	SourceLoader      SourceLoader      // loader of the proto sources
+	CommentPrecedence CommentPrecedence // precedence of leading and trailing comments
+	FieldOrder        FieldOrder        // order of the message fields, enum constants and RPCs
+	Filter            *GetFilter        // filter of the documented elements
+	Links             []*LinkMapping    // external documentation links of types not included in the documentation
+	SourceLink        *SourceLink       // links to the proto source on a repository
+	StyleSheet        string            // additional style, for generators that support it
+	Values            map[string]string // generator specific options, described by GeneratorInfo.Options
+}
+
+func NewGeneratorOptions() *GeneratorOptions {
+	return &GeneratorOptions{
+		Title:  "Documentation",
+		Filter: NewGetFilter(ST_ALIAS_NAME, DT_OWN),
+		Values: make(map[string]string),
+	}
+}
+
+// Get a generator specific option value
+func (o *GeneratorOptions) Value(name string) string {
+	return o.Values[name]
+}
+
+// Get a generator specific boolean option value
+func (o *GeneratorOptions) BoolValue(name string) bool {
+	v, _ := strconv.ParseBool(o.Values[name])
+	return v
+}
+
+// Creates a helper with the options applied
+func (o *GeneratorOptions) NewHelper(dep *fdep.Dep) *Helper {
+	helper := NewHelper(dep)
+	if o.SourceLoader != nil {
+		helper.SetSourceLoader(o.SourceLoader)
+	}
+	return helper
+}
+
+// Type of a generator specific option
+type GeneratorOptionType string
+
+const (
+	GOT_STRING GeneratorOptionType = "string"
+	GOT_BOOL   GeneratorOptionType = "bool"
+	GOT_INT    GeneratorOptionType = "int"
+)
+
+// Description of a generator specific option
+type GeneratorOptionInfo struct {
+	Name        string              `json:"name"`
+	Type        GeneratorOptionType `json:"type"`
+	Default     string              `json:"default,omitempty"`
+	Description string              `json:"description,omitempty"`
+}
+
+// Registered generator
+type GeneratorInfo struct {
+	Name        string                 // name used to select the generator
+	Description string                 // short description
+	FileName    string                 // default output file name
+	Options     []*GeneratorOptionInfo // generator specific options schema
+	Factory     func(options *GeneratorOptions) (Generator, error)
+}
+
+// Creates the generator, validating the generator specific options and setting the default values
+func (gi *GeneratorInfo) NewGenerator(options *GeneratorOptions) (Generator, error) {
+	values := make(map[string]string)
+	for _, oi := range gi.Options {
+		if oi.Default != "" {
+			values[oi.Name] = oi.Default
+		}
+	}
+
+	for name, value := range options.Values {
+		oi := gi.findOption(name)
+		if oi == nil {
+			return nil, fmt.Errorf("Unknown option '%s' for generator %s", name, gi.Name)
+		}
+
+		var err error
+		switch oi.Type {
+		case GOT_BOOL:
+			_, err = strconv.ParseBool(value)
+		case GOT_INT:
+			_, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value '%s' for option '%s' of generator %s: %v", value, name, gi.Name, err)
+		}
+
+		values[name] = value
+	}
+
+	o := *options
+	o.Values = values
+	return gi.Factory(&o)
+}
+
+func (gi *GeneratorInfo) findOption(name string) *GeneratorOptionInfo {
+	for _, oi := range gi.Options {
+		if oi.Name == name {
+			return oi
+		}
+	}
+	return nil
+}
+
+var generators = make(map[string]*GeneratorInfo)
+
+// Register a generator, replacing any generator with the same name
+func RegisterGenerator(info *GeneratorInfo) {
+	generators[info.Name] = info
+}
+
+// Get a registered generator by name
+func GetGenerator(name string) (*GeneratorInfo, error) {
+	if info, ok := generators[name]; ok {
+		return info, nil
+	}
+	return nil, fmt.Errorf("Unknown generator: %s", name)
+}
+
+// Get the names of the registered generators, sorted
+func GeneratorNames() []string {
+	var ret []string
+	for name := range generators {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -1,6 +1,7 @@
 package fproto_doc
 
 import (
+	"regexp"
 	"sort"
 
 	"github.com/RangelReale/fdep"
@@ -26,11 +27,28 @@ const (
 	DT_IMPORTED                      // Only imported dependencies
 )
 
+// Element kinds to filter
+type ElementKind int
+
+const (
+	EK_SERVICE ElementKind = 1 << iota // Services
+	EK_ENUM                            // Enums
+	EK_MESSAGE                         // Messages
+
+	EK_ALL ElementKind = EK_SERVICE | EK_ENUM | EK_MESSAGE // All element kinds
+)
+
 // Filter struct
 type GetFilter struct {
-	SortType      SortType
-	FilterDepType FilterDepType
-	FilePaths     []string
+	SortType        SortType
+	FilterDepType   FilterDepType
+	FilePaths       []string
+	FileGlobs       []*regexp.Regexp // compiled file path globs, matched against the fdep file path, which includes the proto path root prefix
+	Packages        []string         // allowed packages, "pkg.*" also matches subpackages
+	ExcludePackages []string         // denied packages, same format as Packages
+	NamePatterns    []*regexp.Regexp // patterns the full name of the element must match
+	Kinds           ElementKind      // element kinds, 0 means all
+	Exclude         *ExcludeRules
 }
 
 func NewGetFilter(sortType SortType, filterDepType FilterDepType) *GetFilter {
@@ -45,9 +63,44 @@ func (gf *GetFilter) SetFilePaths(filePaths []string) *GetFilter {
 	return gf
 }
 
+func (gf *GetFilter) SetFileGlobs(fileGlobs []*regexp.Regexp) *GetFilter {
+	gf.FileGlobs = fileGlobs
+	return gf
+}
+
+func (gf *GetFilter) SetPackages(packages []string, excludePackages []string) *GetFilter {
+	gf.Packages = packages
+	gf.ExcludePackages = excludePackages
+	return gf
+}
+
+func (gf *GetFilter) SetNamePatterns(namePatterns []*regexp.Regexp) *GetFilter {
+	gf.NamePatterns = namePatterns
+	return gf
+}
+
+func (gf *GetFilter) SetKinds(kinds ElementKind) *GetFilter {
+	gf.Kinds = kinds
+	return gf
+}
+
+func (gf *GetFilter) SetExclude(exclude *ExcludeRules) *GetFilter {
+	gf.Exclude = exclude
+	return gf
+}
+
+// Returns a copy of the filter with another sort and dependency type
+func (gf *GetFilter) With(sortType SortType, filterDepType FilterDepType) *GetFilter {
+	ret := *gf
+	ret.SortType = sortType
+	ret.FilterDepType = filterDepType
+	return &ret
+}
+
 // Doc generator struct
 type Helper struct {
-	dep *fdep.Dep
+	dep    *fdep.Dep
+	source *SourceIndex
 }
 
 // Creates a new doc generator
@@ -57,23 +110,29 @@ func NewHelper(dep *fdep.Dep) *Helper {
 	}
 }
 
+// Sets the loader of the proto source files, used to extract information the parser doesn't keep
+func (g *Helper) SetSourceLoader(loader SourceLoader) *Helper {
+	g.source = NewSourceIndex(loader)
+	return g
+}
+
 // Get a list of all enums using the filter
 func (g *Helper) GetEnumList(filter *GetFilter) []*fdep.DepType {
-	return g.genList(filter, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
+	return g.genList(filter, EK_ENUM, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
 		return pfile.CollectEnums()
 	})
 }
 
 // Get a list of all messages using the filter
 func (g *Helper) GetMessageList(filter *GetFilter) []*fdep.DepType {
-	return g.genList(filter, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
+	return g.genList(filter, EK_MESSAGE, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
 		return pfile.CollectMessages()
 	})
 }
 
 // Get a list of all services using the filter
 func (g *Helper) GetServiceList(filter *GetFilter) []*fdep.DepType {
-	return g.genList(filter, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
+	return g.genList(filter, EK_SERVICE, func(pfile *fproto.ProtoFile) []fproto.FProtoElement {
 		return pfile.CollectServices()
 	})
 }
@@ -213,10 +272,14 @@ func (g *Helper) SortedPackageList(filterDepType FilterDepType) []string {
 }
 
 // Internal list generator
-func (g *Helper) genList(filter *GetFilter, pffunc func(pfile *fproto.ProtoFile) []fproto.FProtoElement) []*fdep.DepType {
+func (g *Helper) genList(filter *GetFilter, kind ElementKind, pffunc func(pfile *fproto.ProtoFile) []fproto.FProtoElement) []*fdep.DepType {
 	collect := make(map[string]*fdep.DepType)
 	var ret []*fdep.DepType
 
+	if !filter.IsKindSelected(kind) {
+		return nil
+	}
+
 	for _, f := range g.dep.Files {
 		include := true
 		switch filter.FilterDepType {
@@ -236,9 +299,16 @@ func (g *Helper) genList(filter *GetFilter, pffunc func(pfile *fproto.ProtoFile)
 			}
 		}
 
+		if include {
+			include = filter.matchFile(f.FilePath) && filter.matchPackage(f.ProtoFile.PackageName)
+		}
+
 		if include {
 			for _, e := range pffunc(f.ProtoFile) {
 				dt := fdep.NewDepTypeFromElement(f, e)
+				if !filter.matchName(dt.FullOriginalName()) || g.IsExcludedType(dt, filter.Exclude) {
+					continue
+				}
 				if filter.SortType == ST_NONE {
 					ret = append(ret, dt)
 				} else {
@@ -0,0 +1,269 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Name of the RPC option with the HTTP bindings
+const HTTPRuleOption = "google.api.http"
+
+// HTTP binding of a RPC, from the google.api.http option
+type HTTPRule struct {
+	Method       string // GET, PUT, POST, DELETE, PATCH or the custom method kind
+	Path         string // path template, like "/v1/{name=messages/*}"
+	Body         string // request field mapped to the body, "*" for all the fields not in the path
+	ResponseBody string // response field mapped to the body, blank for the whole response
+}
+
+// Variable of a path template
+type HTTPPathVar struct {
+	FieldPath string // request field path, like "message.name"
+	Pattern   string // path pattern, "*" if not set
+}
+
+// Get the variables of the path template
+func (r *HTTPRule) PathVars() []*HTTPPathVar {
+	var ret []*HTTPPathVar
+	path := r.Path
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			break
+		}
+
+		v := &HTTPPathVar{FieldPath: path[start+1 : start+end], Pattern: "*"}
+		if pos := strings.Index(v.FieldPath, "="); pos >= 0 {
+			v.FieldPath, v.Pattern = v.FieldPath[:pos], v.FieldPath[pos+1:]
+		}
+		v.FieldPath = strings.TrimSpace(v.FieldPath)
+		ret = append(ret, v)
+
+		path = path[start+end+1:]
+	}
+	return ret
+}
+
+// Get the HTTP bindings of a RPC, including the additional bindings.
+// The option is read from the proto source if available, as the parser doesn't
+// keep nested aggregated values.
+func (g *Helper) GetHTTPRules(dt *fdep.DepType, rpc *fproto.RPCElement) []*HTTPRule {
+	// read from the source
+	if decl := g.GetSourceDecl(dt, rpc.Name); decl != nil {
+		sf := g.GetSourceFile(dt.DepFile.FilePath)
+		if decl.StartLine >= 1 && decl.EndLine <= len(sf.Lines) {
+			if fields := findSourceOption(sf.Lines[decl.StartLine-1:decl.EndLine], HTTPRuleOption); fields != nil {
+				return httpRulesFromText(fields)
+			}
+		}
+	}
+
+	// aggregated values of the option
+	o := FindOption(rpc.Options, HTTPRuleOption)
+	if o == nil {
+		return nil
+	}
+
+	// sorted for a stable order of the fields
+	var names []string
+	for name := range o.AggregatedValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []*textField
+	for _, name := range names {
+		fields = append(fields, &textField{name: name, value: strings.Trim(fmt.Sprint(o.AggregatedValues[name]), `"`)})
+	}
+	return httpRulesFromText(fields)
+}
+
+func httpRulesFromText(fields []*textField) []*HTTPRule {
+	rule := &HTTPRule{}
+	var ret []*HTTPRule
+	for _, f := range fields {
+		switch f.name {
+		case "get", "put", "post", "delete", "patch":
+			rule.Method = strings.ToUpper(f.name)
+			rule.Path = f.value
+		case "custom":
+			for _, cf := range f.message {
+				switch cf.name {
+				case "kind":
+					rule.Method = strings.ToUpper(cf.value)
+				case "path":
+					rule.Path = cf.value
+				}
+			}
+		case "body":
+			rule.Body = f.value
+		case "response_body":
+			rule.ResponseBody = f.value
+		case "additional_bindings":
+			ret = append(ret, httpRulesFromText(f.message)...)
+		}
+	}
+
+	if rule.Method == "" {
+		return ret
+	}
+	return append([]*HTTPRule{rule}, ret...)
+}
+
+// Find a field of a message by its path, like "message.name", returning the
+// message type that contains the last field
+func (g *Helper) FindFieldPath(dt *fdep.DepType, fieldPath string) (*fdep.DepType, *fproto.FieldElement, error) {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		msg, ok := dt.Item.(*fproto.MessageElement)
+		if !ok {
+			return nil, nil, nil
+		}
+
+		fld := findMessageField(msg.Fields, name)
+		if fld == nil {
+			return nil, nil, nil
+		}
+		if i == len(names)-1 {
+			return dt, fld, nil
+		}
+
+		ft, err := dt.FindType(fld.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ft == nil {
+			return nil, nil, nil
+		}
+		dt = ft
+	}
+	return nil, nil, nil
+}
+
+// Find a field by name, including the fields of oneofs
+func findMessageField(fields []fproto.FieldElementTag, name string) *fproto.FieldElement {
+	for _, fld := range fields {
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			if xfld.Name == name {
+				return xfld
+			}
+		case *fproto.MapFieldElement:
+			if xfld.Name == name {
+				return xfld.FieldElement
+			}
+		case *fproto.OneOfFieldElement:
+			if f := findMessageField(xfld.Fields, name); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Field of an aggregated option value in the protobuf text format
+type textField struct {
+	name    string
+	value   string
+	message []*textField
+}
+
+// Find an option in the source lines and parse its aggregated value.
+// Returns nil if the option is not found or is not aggregated.
+func findSourceOption(lines []string, name string) []*textField {
+	var tks []srcToken
+	for _, tk := range scanSource(strings.Join(lines, "\n")) {
+		if !tk.comment {
+			tks = append(tks, tk)
+		}
+	}
+
+	for i := 0; i+5 < len(tks); i++ {
+		if tks[i].text == "option" && tks[i+1].text == "(" && tks[i+2].text == name && tks[i+3].text == ")" &&
+			tks[i+4].text == "=" && tks[i+5].text == "{" {
+			pos := i + 6
+			return parseTextMessage(tks, &pos)
+		}
+	}
+	return nil
+}
+
+// Parse the fields of a message in the protobuf text format, until the closing brace
+func parseTextMessage(tks []srcToken, pos *int) []*textField {
+	var ret []*textField
+	for *pos < len(tks) && tks[*pos].text != "}" {
+		f := &textField{name: tks[*pos].text}
+		*pos++
+
+		if *pos < len(tks) && tks[*pos].text == ":" {
+			*pos++
+		}
+		if *pos >= len(tks) {
+			break
+		}
+
+		if tks[*pos].text == "[" {
+			// list of values, the same as repeating the field
+			*pos++
+			for *pos < len(tks) && tks[*pos].text != "]" {
+				if tks[*pos].text == "," {
+					*pos++
+					continue
+				}
+				lf := &textField{name: f.name}
+				parseTextValue(tks, pos, lf)
+				ret = append(ret, lf)
+			}
+			*pos++ // closing bracket
+		} else {
+			parseTextValue(tks, pos, f)
+			ret = append(ret, f)
+		}
+
+		if *pos < len(tks) && (tks[*pos].text == "," || tks[*pos].text == ";") {
+			*pos++
+		}
+	}
+	return ret
+}
+
+// Parse a message or scalar value of a field in the protobuf text format
+func parseTextValue(tks []srcToken, pos *int, f *textField) {
+	switch {
+	case tks[*pos].text == "{":
+		*pos++
+		f.message = parseTextMessage(tks, pos)
+		*pos++ // closing brace
+	case tks[*pos].text == "-" && *pos+1 < len(tks):
+		f.value = "-" + tks[*pos+1].text
+		*pos += 2
+	default:
+		f.value = textValue(tks[*pos].text)
+		*pos++
+	}
+}
+
+// Value of a scalar token, unquoting the strings
+func textValue(s string) string {
+	if strings.HasPrefix(s, "'") && len(s) >= 2 {
+		// as a double quoted string, to unquote the escapes
+		s = `"` + singleQuoteReplacer.Replace(s[1:len(s)-1]) + `"`
+	}
+	if strings.HasPrefix(s, `"`) {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	return strings.Trim(s, `"'`)
+}
+
+var singleQuoteReplacer = strings.NewReplacer(`\\`, `\\`, `\'`, `'`, `\"`, `\"`, `"`, `\"`)
@@ -0,0 +1,165 @@
+package fproto_doc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Parse a message in the protobuf text format, without the braces
+func parseTestTextMessage(s string) []*textField {
+	var tks []srcToken
+	for _, tk := range scanSource(s) {
+		if !tk.comment {
+			tks = append(tks, tk)
+		}
+	}
+	pos := 0
+	return parseTextMessage(tks, &pos)
+}
+
+func TestParseTextMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		fields []*textField
+	}{
+		{
+			name:   "scalars",
+			source: `get: "/v1/{name=items/*}" body: "*"; min_len: 1, gte: -1 required: true`,
+			fields: []*textField{
+				{name: "get", value: "/v1/{name=items/*}"},
+				{name: "body", value: "*"},
+				{name: "min_len", value: "1"},
+				{name: "gte", value: "-1"},
+				{name: "required", value: "true"},
+			},
+		},
+		{
+			name:   "nested messages",
+			source: `custom { kind: "HEAD" path: "/v1/items" } items: { string: { email: true } }`,
+			fields: []*textField{
+				{name: "custom", message: []*textField{
+					{name: "kind", value: "HEAD"},
+					{name: "path", value: "/v1/items"},
+				}},
+				{name: "items", message: []*textField{
+					{name: "string", message: []*textField{
+						{name: "email", value: "true"},
+					}},
+				}},
+			},
+		},
+		{
+			name:   "lists",
+			source: `in: ["a", "b"], not_in: [1, -2] bindings: [{get: "/a"}, {get: "/b"}] other: []`,
+			fields: []*textField{
+				{name: "in", value: "a"},
+				{name: "in", value: "b"},
+				{name: "not_in", value: "1"},
+				{name: "not_in", value: "-2"},
+				{name: "bindings", message: []*textField{{name: "get", value: "/a"}}},
+				{name: "bindings", message: []*textField{{name: "get", value: "/b"}}},
+			},
+		},
+		{
+			name:   "quoted strings with escapes",
+			source: `pattern: "^[a-z]+\\.\"x\"$" single: 'it\'s "quoted"' brace: "}" comment: "// not a comment"`,
+			fields: []*textField{
+				{name: "pattern", value: `^[a-z]+\."x"$`},
+				{name: "single", value: `it's "quoted"`},
+				{name: "brace", value: "}"},
+				{name: "comment", value: "// not a comment"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := parseTestTextMessage(tt.source)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("got %s, want %s", textFieldsString(fields), textFieldsString(tt.fields))
+			}
+		})
+	}
+}
+
+func textFieldsString(fields []*textField) string {
+	var ret []string
+	for _, f := range fields {
+		if f.message != nil {
+			ret = append(ret, f.name+" {"+textFieldsString(f.message)+"}")
+		} else {
+			ret = append(ret, f.name+": "+f.value)
+		}
+	}
+	return strings.Join(ret, " ")
+}
+
+func TestHTTPRulesFromSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		rules  []*HTTPRule
+	}{
+		{
+			name: "single binding",
+			source: `rpc GetItem(GetItemRequest) returns (Item) {
+  option (google.api.http).get = "/ignored";
+  option (google.api.http) = { get: "/v1/{name=items/*}" };
+}`,
+			rules: []*HTTPRule{
+				{Method: "GET", Path: "/v1/{name=items/*}"},
+			},
+		},
+		{
+			name: "additional bindings",
+			source: `rpc UpdateItem(UpdateItemRequest) returns (Item) {
+  option (google.api.http) = {
+    // the main binding
+    patch: "/v1/{item.name=items/*}"
+    body: "item"
+    response_body: "item"
+    additional_bindings {
+      put: "/v1/{item.name=items/*}" /* put */
+      body: "*"
+    }
+    additional_bindings: [{post: "/v1/items:update" body: "*"}, {custom: {kind: "HEAD", path: "/v1/items"}}]
+  };
+}`,
+			rules: []*HTTPRule{
+				{Method: "PATCH", Path: "/v1/{item.name=items/*}", Body: "item", ResponseBody: "item"},
+				{Method: "PUT", Path: "/v1/{item.name=items/*}", Body: "*"},
+				{Method: "POST", Path: "/v1/items:update", Body: "*"},
+				{Method: "HEAD", Path: "/v1/items"},
+			},
+		},
+		{
+			name:   "no option",
+			source: `rpc GetItem(GetItemRequest) returns (Item);`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := httpRulesFromText(findSourceOption(strings.Split(tt.source, "\n"), HTTPRuleOption))
+			if !reflect.DeepEqual(rules, tt.rules) {
+				for _, r := range rules {
+					t.Logf("got %+v", r)
+				}
+				t.Errorf("rules don't match")
+			}
+		})
+	}
+}
+
+func TestHTTPRulePathVars(t *testing.T) {
+	rule := &HTTPRule{Path: "/v1/{parent=shelves/*}/books/{ book.id }:get"}
+	want := []*HTTPPathVar{
+		{FieldPath: "parent", Pattern: "shelves/*"},
+		{FieldPath: "book.id", Pattern: "*"},
+	}
+	if got := rule.PathVars(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package fproto_doc
+
+import (
+	"strings"
+
+	"github.com/RangelReale/fproto"
+)
+
+// JSON type of a value in the proto3 JSON mapping
+type JSONType struct {
+	Type     string // JSON schema type, blank for any value
+	Format   string // JSON schema format
+	Nullable bool   // null is a valid value, like for the wrapper types
+}
+
+// JSON types of the scalar types in the proto3 JSON mapping.
+// 64-bit integers are encoded as strings.
+var scalarJSONTypes = map[string]JSONType{
+	"double":   {Type: "number", Format: "double"},
+	"float":    {Type: "number", Format: "float"},
+	"int32":    {Type: "integer", Format: "int32"},
+	"sint32":   {Type: "integer", Format: "int32"},
+	"sfixed32": {Type: "integer", Format: "int32"},
+	"uint32":   {Type: "integer", Format: "uint32"},
+	"fixed32":  {Type: "integer", Format: "uint32"},
+	"int64":    {Type: "string", Format: "int64"},
+	"sint64":   {Type: "string", Format: "int64"},
+	"sfixed64": {Type: "string", Format: "int64"},
+	"uint64":   {Type: "string", Format: "uint64"},
+	"fixed64":  {Type: "string", Format: "uint64"},
+	"bool":     {Type: "boolean", Format: ""},
+	"string":   {Type: "string", Format: ""},
+	"bytes":    {Type: "string", Format: "byte"},
+}
+
+// Get the JSON type of a scalar type
+func ScalarJSONType(typeName string) (JSONType, bool) {
+	jt, ok := scalarJSONTypes[typeName]
+	return jt, ok
+}
+
+// Get the JSON type of a well-known type by its full name, from the well-known types catalog
+func WellKnownJSONType(fullName string) (JSONType, bool) {
+	if wkt := FindWellKnownType(fullName); wkt != nil {
+		return wkt.JSONType, true
+	}
+	return JSONType{}, false
+}
+
+// Get the JSON name of a field, the json_name option or the lowerCamelCase field name
+func FieldJSONName(fld *fproto.FieldElement) string {
+	if o := FindOption(fld.Options, "json_name"); o != nil {
+		return OptionValue(o)
+	}
+	return JSONName(fld.Name)
+}
+
+// Converts a field name to lowerCamelCase, as protoc does for the JSON names
+func JSONName(name string) string {
+	var ret strings.Builder
+	upper := false
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		ret.WriteRune(c)
+	}
+	return ret.String()
+}
@@ -0,0 +1,354 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Language of the generated code
+type Language string
+
+const (
+	LANG_GO         Language = "go"
+	LANG_TYPESCRIPT Language = "ts"
+	LANG_JAVA       Language = "java"
+)
+
+// Get the display name of the language
+func (l Language) Title() string {
+	switch l {
+	case LANG_GO:
+		return "Go"
+	case LANG_TYPESCRIPT:
+		return "TypeScript"
+	case LANG_JAVA:
+		return "Java"
+	}
+	return string(l)
+}
+
+// Parse a list of languages separated by commas, like "go,ts,java"
+func ParseLanguages(names string) ([]Language, error) {
+	var ret []Language
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "go":
+			ret = append(ret, LANG_GO)
+		case "ts", "typescript":
+			ret = append(ret, LANG_TYPESCRIPT)
+		case "java":
+			ret = append(ret, LANG_JAVA)
+		default:
+			return nil, fmt.Errorf("Unknown language: %s", name)
+		}
+	}
+	return ret, nil
+}
+
+// Generated type and accessor of a field in a language
+type LanguageField struct {
+	Language Language
+	Type     string // type name, like "*timestamppb.Timestamp"
+	Accessor string // getter, like "GetCreateTime()"
+}
+
+// Get the generated type name and accessor of a field of a message in a language,
+// following the naming rules of protoc-gen-go, protoc-gen-es and protoc-gen-java.
+// Go types of the same package are not qualified, Java types are fully qualified.
+func (g *Helper) GetLanguageField(lang Language, dt *fdep.DepType, fld fproto.FieldElementTag) (*LanguageField, error) {
+	ret := &LanguageField{Language: lang}
+
+	switch xfld := fld.(type) {
+	case *fproto.FieldElement:
+		tp, err := g.languageTypeName(lang, dt, xfld.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		switch lang {
+		case LANG_GO:
+			switch {
+			case xfld.Repeated:
+				tp = "[]" + tp
+			case xfld.Optional && FindScalarType(xfld.Type) != nil:
+				tp = "*" + tp
+			}
+			ret.Accessor = "Get" + GoCamelCase(xfld.Name) + "()"
+		case LANG_TYPESCRIPT:
+			_, is_enum := g.findLanguageType(dt, xfld.Type).(*fproto.EnumElement)
+			switch {
+			case xfld.Repeated:
+				tp += "[]"
+			case xfld.Optional || (FindScalarType(xfld.Type) == nil && !is_enum):
+				// message fields may be unset
+				tp += " | undefined"
+			}
+			ret.Accessor = JSONName(xfld.Name)
+		case LANG_JAVA:
+			if xfld.Repeated {
+				tp = "java.util.List<" + javaBoxedType(tp) + ">"
+				ret.Accessor = "get" + JavaCamelCase(xfld.Name) + "List()"
+			} else {
+				ret.Accessor = "get" + JavaCamelCase(xfld.Name) + "()"
+			}
+		}
+		ret.Type = tp
+	case *fproto.MapFieldElement:
+		key, err := g.languageTypeName(lang, dt, xfld.KeyType)
+		if err != nil {
+			return nil, err
+		}
+		value, err := g.languageTypeName(lang, dt, xfld.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		switch lang {
+		case LANG_GO:
+			ret.Type = fmt.Sprintf("map[%s]%s", key, value)
+			ret.Accessor = "Get" + GoCamelCase(xfld.Name) + "()"
+		case LANG_TYPESCRIPT:
+			if key == "bigint" || key == "boolean" {
+				// object keys are strings
+				key = "string"
+			}
+			ret.Type = fmt.Sprintf("{ [key: %s]: %s }", key, value)
+			ret.Accessor = JSONName(xfld.Name)
+		case LANG_JAVA:
+			ret.Type = fmt.Sprintf("java.util.Map<%s, %s>", javaBoxedType(key), javaBoxedType(value))
+			ret.Accessor = "get" + JavaCamelCase(xfld.Name) + "Map()"
+		}
+	default:
+		return nil, nil
+	}
+
+	return ret, nil
+}
+
+// Find the element of a type, or nil if not found
+func (g *Helper) findLanguageType(parent *fdep.DepType, typeName string) fproto.FProtoElement {
+	ft, err := parent.FindType(typeName)
+	if err != nil || ft == nil {
+		return nil
+	}
+	return ft.Item
+}
+
+// Get the generated name of a type in a language, relative to the parent type
+func (g *Helper) languageTypeName(lang Language, parent *fdep.DepType, typeName string) (string, error) {
+	if st := FindScalarType(typeName); st != nil {
+		switch lang {
+		case LANG_GO:
+			return st.Go, nil
+		case LANG_TYPESCRIPT:
+			return st.JS, nil
+		case LANG_JAVA:
+			if st.Name == "bytes" {
+				return "com.google.protobuf.ByteString", nil
+			}
+			return st.Java, nil
+		}
+	}
+
+	ft, err := parent.FindType(typeName)
+	if err != nil {
+		return "", err
+	}
+
+	full_name := strings.TrimPrefix(typeName, ".")
+	if ft != nil {
+		full_name = ft.FullOriginalName()
+	}
+
+	// well-known types, as they may not have their files loaded
+	if wkt := FindWellKnownType(full_name); wkt != nil {
+		switch lang {
+		case LANG_GO:
+			return wkt.Go, nil
+		case LANG_TYPESCRIPT:
+			return wkt.TS, nil
+		case LANG_JAVA:
+			return wkt.Java, nil
+		}
+	}
+
+	// types not loaded
+	if ft == nil || ft.DepFile == nil {
+		name := full_name[strings.LastIndex(full_name, ".")+1:]
+		switch lang {
+		case LANG_GO:
+			return "*" + name, nil
+		case LANG_JAVA:
+			if strings.HasPrefix(full_name, "google.protobuf.") {
+				return "com.google.protobuf." + name, nil
+			}
+		}
+		return name, nil
+	}
+
+	_, is_enum := ft.Item.(*fproto.EnumElement)
+
+	switch lang {
+	case LANG_GO:
+		name := strings.Replace(ft.Name, ".", "_", -1)
+		pkg := goPackageName(ft.DepFile)
+		if parent.DepFile == nil || pkg != goPackageName(parent.DepFile) {
+			name = pkg + "." + name
+		}
+		if !is_enum {
+			name = "*" + name
+		}
+		return name, nil
+	case LANG_TYPESCRIPT:
+		return strings.Replace(ft.Name, ".", "_", -1), nil
+	case LANG_JAVA:
+		return javaClassName(ft), nil
+	}
+	return ft.Name, nil
+}
+
+// Get the Go package name of a file, from the go_package option
+func goPackageName(df *fdep.DepFile) string {
+	if df == nil || df.ProtoFile == nil {
+		return ""
+	}
+
+	gp := OptionValue(FindOption(df.ProtoFile.Options, "go_package"))
+	if pos := strings.LastIndex(gp, ";"); pos >= 0 {
+		return gp[pos+1:]
+	}
+	if gp == "" {
+		// protoc-gen-go requires go_package, use the proto package
+		gp = df.ProtoFile.PackageName
+		gp = gp[strings.LastIndex(gp, ".")+1:]
+	}
+	if gp == "" {
+		// or the file name, as older protoc-gen-go versions
+		gp = strings.TrimSuffix(path.Base(df.FilePath), ".proto")
+	}
+	return strings.NewReplacer("-", "_", ".", "_").Replace(path.Base(gp))
+}
+
+// Get the fully qualified Java class name of a type
+func javaClassName(dt *fdep.DepType) string {
+	pf := dt.DepFile.ProtoFile
+
+	pkg := OptionValue(FindOption(pf.Options, "java_package"))
+	if pkg == "" {
+		pkg = pf.PackageName
+	}
+
+	name := dt.Name
+	if OptionValue(FindOption(pf.Options, "java_multiple_files")) != "true" {
+		name = javaOuterClassName(dt.DepFile) + "." + name
+	}
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	return name
+}
+
+// Get the Java outer class name of a file, from the java_outer_classname option
+// or the file name, with "OuterClass" appended if it conflicts with a type
+func javaOuterClassName(df *fdep.DepFile) string {
+	pf := df.ProtoFile
+	if name := OptionValue(FindOption(pf.Options, "java_outer_classname")); name != "" {
+		return name
+	}
+
+	name := JavaCamelCase(strings.TrimSuffix(path.Base(df.FilePath), ".proto"))
+	for _, m := range pf.Messages {
+		if m.Name == name {
+			return name + "OuterClass"
+		}
+	}
+	for _, e := range pf.Enums {
+		if e.Name == name {
+			return name + "OuterClass"
+		}
+	}
+	for _, s := range pf.Services {
+		if s.Name == name {
+			return name + "OuterClass"
+		}
+	}
+	return name
+}
+
+// Get the boxed Java type of a primitive type
+func javaBoxedType(tp string) string {
+	switch tp {
+	case "int":
+		return "Integer"
+	case "long":
+		return "Long"
+	case "float":
+		return "Float"
+	case "double":
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	}
+	return tp
+}
+
+// Converts a field name to CamelCase, as protoc-gen-go does for the Go names.
+// Words start uppercased, underscores followed by a lowercase letter are removed
+// and other underscores are kept.
+func GoCamelCase(name string) string {
+	var ret strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '.' && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z':
+		case c == '.':
+			ret.WriteByte('_')
+		case c == '_' && (i == 0 || name[i-1] == '.'):
+			ret.WriteByte('X')
+		case c == '_' && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z':
+		case c >= '0' && c <= '9':
+			ret.WriteByte(c)
+		default:
+			if c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			ret.WriteByte(c)
+			for i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z' {
+				i++
+				ret.WriteByte(name[i])
+			}
+		}
+	}
+	return ret.String()
+}
+
+// Converts a name to CamelCase, as protoc-gen-java does for the accessors and class names.
+// Characters other than letters and digits are removed, and the letters after them
+// or after digits are uppercased.
+func JavaCamelCase(name string) string {
+	var ret strings.Builder
+	upper := true
+	for _, c := range name {
+		switch {
+		case c >= '0' && c <= '9':
+			ret.WriteRune(c)
+			upper = true
+			continue
+		case c >= 'a' && c <= 'z':
+			if upper {
+				c -= 'a' - 'A'
+			}
+		case c < 'A' || c > 'Z':
+			upper = true
+			continue
+		}
+		upper = false
+		ret.WriteRune(c)
+	}
+	return ret.String()
+}
@@ -0,0 +1,128 @@
+package fproto_doc
+
+import (
+	"testing"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+func TestGoCamelCase(t *testing.T) {
+	// outputs of protoc-gen-go
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"foo", "Foo"},
+		{"foo_bar", "FooBar"},
+		{"foo_bar_1", "FooBar_1"},
+		{"foo__bar", "Foo_Bar"},
+		{"foo_Bar", "Foo_Bar"},
+		{"fooBar", "FooBar"},
+		{"FooBar", "FooBar"},
+		{"_x", "XX"},
+		{"_foo", "XFoo"},
+		{"x2y", "X2Y"},
+		{"foo_2bar", "Foo_2Bar"},
+		{"a.b", "AB"},
+		{"a.B", "A_B"},
+		{"a._b", "A_XB"},
+		{"HTTPRequest", "HTTPRequest"},
+	}
+
+	for _, tt := range tests {
+		if got := GoCamelCase(tt.name); got != tt.want {
+			t.Errorf("GoCamelCase(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJavaCamelCase(t *testing.T) {
+	// outputs of protoc-gen-java
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"foo", "Foo"},
+		{"foo_bar", "FooBar"},
+		{"foo_bar_1", "FooBar1"},
+		{"foo__bar", "FooBar"},
+		{"foo_Bar", "FooBar"},
+		{"fooBar", "FooBar"},
+		{"_x", "X"},
+		{"x2y", "X2Y"},
+		{"a.b", "AB"},
+		{"my-file.v1", "MyFileV1"},
+		{"HTTPRequest", "HTTPRequest"},
+	}
+
+	for _, tt := range tests {
+		if got := JavaCamelCase(tt.name); got != tt.want {
+			t.Errorf("JavaCamelCase(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	tests := []struct {
+		filePath  string
+		pkg       string
+		goPackage string
+		want      string
+	}{
+		{"api/v1/api.proto", "myorg.api.v1", "example.com/api/v1;apiv1", "apiv1"},
+		{"api/v1/api.proto", "myorg.api.v1", "example.com/api/v1", "v1"},
+		{"api/api.proto", "myorg.api", "example.com/my-api", "my_api"},
+		{"api/api.proto", "myorg.api", "example.com/foo.v2", "foo_v2"},
+		{"billing/billing.proto", "myorg.billing", "", "billing"},
+		{"dir/my-file.proto", "", "", "my_file"},
+	}
+
+	for _, tt := range tests {
+		df := &fdep.DepFile{FilePath: tt.filePath, ProtoFile: &fproto.ProtoFile{PackageName: tt.pkg}}
+		if tt.goPackage != "" {
+			df.ProtoFile.Options = []*fproto.OptionElement{{Name: "go_package", Value: fproto.OptionValue{Value: tt.goPackage}}}
+		}
+		if got := goPackageName(df); got != tt.want {
+			t.Errorf("goPackageName(%q, %q) = %q, want %q", tt.filePath, tt.goPackage, got, tt.want)
+		}
+	}
+}
+
+func TestJavaOuterClassName(t *testing.T) {
+	tests := []struct {
+		name      string
+		filePath  string
+		outerName string
+		pf        *fproto.ProtoFile
+		want      string
+	}{
+		{"file name", "api/bar_service.proto", "", &fproto.ProtoFile{}, "BarService"},
+		{"separators", "api/my-file.v1.proto", "", &fproto.ProtoFile{}, "MyFileV1"},
+		{"option", "api/invoice.proto", "Billing", &fproto.ProtoFile{
+			Messages: []*fproto.MessageElement{{Name: "Invoice"}},
+		}, "Billing"},
+		{"conflicting message", "api/invoice.proto", "", &fproto.ProtoFile{
+			Messages: []*fproto.MessageElement{{Name: "Invoice"}},
+		}, "InvoiceOuterClass"},
+		{"conflicting enum", "api/status.proto", "", &fproto.ProtoFile{
+			Enums: []*fproto.EnumElement{{Name: "Status"}},
+		}, "StatusOuterClass"},
+		{"conflicting service", "api/billing_service.proto", "", &fproto.ProtoFile{
+			Services: []*fproto.ServiceElement{{Name: "BillingService"}},
+		}, "BillingServiceOuterClass"},
+		{"no conflict", "api/invoice.proto", "", &fproto.ProtoFile{
+			Messages: []*fproto.MessageElement{{Name: "InvoiceItem"}},
+		}, "Invoice"},
+	}
+
+	for _, tt := range tests {
+		if tt.outerName != "" {
+			tt.pf.Options = []*fproto.OptionElement{{Name: "java_outer_classname", Value: fproto.OptionValue{Value: tt.outerName}}}
+		}
+		df := &fdep.DepFile{FilePath: tt.filePath, ProtoFile: tt.pf}
+		if got := javaOuterClassName(df); got != tt.want {
+			t.Errorf("%s: javaOuterClassName(%q) = %q, want %q", tt.name, tt.filePath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package fproto_doc
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+)
+
+// Maps the types of a package to an external documentation URL
+type LinkMapping struct {
+	Package string // package pattern, "pkg.*" also matches subpackages
+	URL     string // URL template, with {package}, {name} and {fullname} placeholders
+}
+
+// Get the external URL of a type, or a blank string if no mapping matches
+func FindLinkURL(mappings []*LinkMapping, dt *fdep.DepType) string {
+	if dt.DepFile == nil {
+		return ""
+	}
+
+	pkg := dt.DepFile.ProtoFile.PackageName
+	for _, m := range mappings {
+		if MatchPackage(m.Package, pkg) {
+			return strings.NewReplacer(
+				"{package}", pkg,
+				"{name}", dt.Name,
+				"{fullname}", dt.FullOriginalName(),
+			).Replace(m.URL)
+		}
+	}
+	return ""
+}
+
+// Links to the proto source on a repository
+type SourceLink struct {
+	Template string       // URL template, with {commit}, {path} and {line} placeholders
+	Commit   string       // source commit
+	Roots    []SourceRoot // proto path roots, removed from the fdep file paths
+}
+
+// Get the path of a fdep file relative to its proto path directory, removing
+// the longest matching root prefix
+func (sl *SourceLink) RootPath(filePath string) string {
+	root := ""
+	for _, r := range sl.Roots {
+		rp := strings.Trim(r.Root, "/")
+		if rp != "" && len(rp) > len(root) && strings.HasPrefix(filePath, rp+"/") {
+			root = rp
+		}
+	}
+	if root == "" {
+		return filePath
+	}
+	return filePath[len(root)+1:]
+}
+
+// Get the URL of a line of a proto file. If line is 0, the URL fragment
+// containing the {line} placeholder is removed.
+// The {path} placeholder is the escaped file path without the proto path root.
+func (sl *SourceLink) URL(filePath string, line int) string {
+	if sl == nil || sl.Template == "" {
+		return ""
+	}
+
+	tpl := sl.Template
+	lineStr := ""
+	if line > 0 {
+		lineStr = strconv.Itoa(line)
+	} else if pos := strings.LastIndex(tpl, "#"); pos >= 0 && strings.Contains(tpl[pos:], "{line}") {
+		tpl = tpl[:pos]
+	}
+
+	segments := strings.Split(sl.RootPath(filePath), "/")
+	for sidx, seg := range segments {
+		segments[sidx] = url.PathEscape(seg)
+	}
+
+	return strings.NewReplacer(
+		"{commit}", sl.Commit,
+		"{path}", strings.Join(segments, "/"),
+		"{line}", lineStr,
+	).Replace(tpl)
+}
+
+// Get the source URL of a type, or of a declaration inside it if name isn't blank.
+// Types without a source declaration link to the file, declarations inside them
+// aren't linked.
+func (g *Helper) SourceURL(sl *SourceLink, dt *fdep.DepType, name string) string {
+	if sl == nil || dt.DepFile == nil {
+		return ""
+	}
+
+	line := 0
+	if decl := g.GetSourceDecl(dt, name); decl != nil {
+		line = decl.StartLine
+	} else if name != "" {
+		return ""
+	}
+	return sl.URL(dt.DepFile.FilePath, line)
+}
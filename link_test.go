@@ -0,0 +1,31 @@
+package fproto_doc
+
+import (
+	"testing"
+)
+
+func TestSourceLinkURL(t *testing.T) {
+	sl := &SourceLink{
+		Template: "https://example.com/blob/{commit}/proto/{path}#L{line}",
+		Commit:   "abc123",
+		Roots:    []SourceRoot{{Dir: "proto", Root: "myorg"}, {Dir: "proto-api", Root: "myorg/api"}, {Dir: "other"}},
+	}
+
+	tests := []struct {
+		filePath string
+		line     int
+		want     string
+	}{
+		{"myorg/foo.proto", 10, "https://example.com/blob/abc123/proto/foo.proto#L10"},
+		{"myorg/foo.proto", 0, "https://example.com/blob/abc123/proto/foo.proto"},
+		{"myorg/api/v1/api.proto", 5, "https://example.com/blob/abc123/proto/v1/api.proto#L5"},
+		{"myorgx/foo.proto", 1, "https://example.com/blob/abc123/proto/myorgx/foo.proto#L1"},
+		{"myorg/my file#1.proto", 2, "https://example.com/blob/abc123/proto/my%20file%231.proto#L2"},
+	}
+
+	for _, tt := range tests {
+		if got := sl.URL(tt.filePath, tt.line); got != tt.want {
+			t.Errorf("URL(%q, %d) = %q, want %q", tt.filePath, tt.line, got, tt.want)
+		}
+	}
+}
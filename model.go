@@ -0,0 +1,298 @@
+package fproto_doc
+
+import (
+	"sort"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Documentation model, a serializable representation of the documented elements
+type Model struct {
+	Title    string          `json:"title,omitempty"`
+	Packages []*ModelPackage `json:"packages"`
+}
+
+type ModelPackage struct {
+	Name     string          `json:"name"`
+	Services []*ModelService `json:"services,omitempty"`
+	Enums    []*ModelEnum    `json:"enums,omitempty"`
+	Messages []*ModelMessage `json:"messages,omitempty"`
+}
+
+type ModelService struct {
+	Name        string      `json:"name"`
+	FullName    string      `json:"full_name"`
+	File        string      `json:"file"`
+	Description string      `json:"description,omitempty"`
+	RPCs        []*ModelRPC `json:"rpcs"`
+}
+
+type ModelRPC struct {
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	RequestType       string `json:"request_type"`
+	RequestFullType   string `json:"request_full_type"`
+	RequestStreaming  bool   `json:"request_streaming,omitempty"`
+	ResponseType      string `json:"response_type"`
+	ResponseFullType  string `json:"response_full_type"`
+	ResponseStreaming bool   `json:"response_streaming,omitempty"`
+}
+
+type ModelEnum struct {
+	Name        string            `json:"name"`
+	FullName    string            `json:"full_name"`
+	File        string            `json:"file"`
+	Description string            `json:"description,omitempty"`
+	Values      []*ModelEnumValue `json:"values"`
+}
+
+type ModelEnumValue struct {
+	Name        string `json:"name"`
+	Number      int    `json:"number"`
+	Description string `json:"description,omitempty"`
+}
+
+type ModelMessage struct {
+	Name        string        `json:"name"`
+	FullName    string        `json:"full_name"`
+	File        string        `json:"file"`
+	Description string        `json:"description,omitempty"`
+	Fields      []*ModelField `json:"fields"`
+	Oneofs      []*ModelOneof `json:"oneofs,omitempty"`
+}
+
+type ModelField struct {
+	Name        string                 `json:"name"`
+	Number      int                    `json:"number"`
+	Label       string                 `json:"label,omitempty"` // repeated, optional or required
+	Type        string                 `json:"type"`
+	FullType    string                 `json:"full_type"`
+	KeyType     string                 `json:"key_type,omitempty"` // for maps
+	Oneof       string                 `json:"oneof,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Validation  []*ModelValidationRule `json:"validation,omitempty"`
+}
+
+type ModelValidationRule struct {
+	Rule   string   `json:"rule"` // rule path, like "string.min_len"
+	Values []string `json:"values"`
+	Text   string   `json:"text"` // human-readable text, like "min length 1"
+}
+
+type ModelOneof struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Fields      []string `json:"fields"`
+}
+
+// Build the documentation model of the elements selected by the filter
+func (g *Helper) BuildModel(filter *GetFilter, precedence CommentPrecedence, order FieldOrder) (*Model, error) {
+	ret := &Model{Packages: []*ModelPackage{}}
+
+	sfilter := filter.With(ST_ALIAS_NAME, filter.FilterDepType)
+
+	packages := make(map[string]*ModelPackage)
+	getPackage := func(dt *fdep.DepType) *ModelPackage {
+		if p, ok := packages[dt.Alias]; ok {
+			return p
+		}
+		p := &ModelPackage{Name: dt.Alias}
+		packages[dt.Alias] = p
+		return p
+	}
+
+	for _, dt := range g.GetServiceList(sfilter) {
+		s, err := g.buildModelService(dt, filter, precedence, order)
+		if err != nil {
+			return nil, err
+		}
+		p := getPackage(dt)
+		p.Services = append(p.Services, s)
+	}
+
+	for _, dt := range g.GetEnumList(sfilter) {
+		p := getPackage(dt)
+		p.Enums = append(p.Enums, g.buildModelEnum(dt, filter, precedence, order))
+	}
+
+	for _, dt := range g.GetMessageList(sfilter) {
+		m, err := g.buildModelMessage(dt, filter, precedence, order)
+		if err != nil {
+			return nil, err
+		}
+		p := getPackage(dt)
+		p.Messages = append(p.Messages, m)
+	}
+
+	var pnames []string
+	for pn := range packages {
+		pnames = append(pnames, pn)
+	}
+	sort.Strings(pnames)
+
+	for _, pn := range pnames {
+		ret.Packages = append(ret.Packages, packages[pn])
+	}
+
+	return ret, nil
+}
+
+func (g *Helper) buildModelService(dt *fdep.DepType, filter *GetFilter, precedence CommentPrecedence, order FieldOrder) (*ModelService, error) {
+	element := dt.Item.(*fproto.ServiceElement)
+
+	ret := &ModelService{
+		Name:        dt.Name,
+		FullName:    dt.FullOriginalName(),
+		File:        dt.DepFile.FilePath,
+		Description: CommentText(element.Comment),
+	}
+
+	for _, rpc := range g.OrderedRPCList(g.FilterRPCList(dt, element.RPCs, filter.Exclude), order) {
+		req_type, err := g.modelTypeName(dt, rpc.RequestType)
+		if err != nil {
+			return nil, err
+		}
+		resp_type, err := g.modelTypeName(dt, rpc.ResponseType)
+		if err != nil {
+			return nil, err
+		}
+
+		ret.RPCs = append(ret.RPCs, &ModelRPC{
+			Name:              rpc.Name,
+			Description:       CommentText(g.ItemComment(dt, rpc.Name, rpc.Comment, precedence)),
+			RequestType:       rpc.RequestType,
+			RequestFullType:   req_type,
+			RequestStreaming:  rpc.StreamsRequest,
+			ResponseType:      rpc.ResponseType,
+			ResponseFullType:  resp_type,
+			ResponseStreaming: rpc.StreamsResponse,
+		})
+	}
+
+	return ret, nil
+}
+
+func (g *Helper) buildModelEnum(dt *fdep.DepType, filter *GetFilter, precedence CommentPrecedence, order FieldOrder) *ModelEnum {
+	element := dt.Item.(*fproto.EnumElement)
+
+	ret := &ModelEnum{
+		Name:        dt.Name,
+		FullName:    dt.FullOriginalName(),
+		File:        dt.DepFile.FilePath,
+		Description: CommentText(element.Comment),
+	}
+
+	for _, ec := range g.OrderedEnumConstantList(g.FilterEnumConstantList(dt, element.EnumConstants, filter.Exclude), order) {
+		ret.Values = append(ret.Values, &ModelEnumValue{
+			Name:        ec.Name,
+			Number:      ec.Tag,
+			Description: CommentText(g.ItemComment(dt, ec.Name, ec.Comment, precedence)),
+		})
+	}
+
+	return ret
+}
+
+func (g *Helper) buildModelMessage(dt *fdep.DepType, filter *GetFilter, precedence CommentPrecedence, order FieldOrder) (*ModelMessage, error) {
+	element := dt.Item.(*fproto.MessageElement)
+
+	ret := &ModelMessage{
+		Name:        dt.Name,
+		FullName:    dt.FullOriginalName(),
+		File:        dt.DepFile.FilePath,
+		Description: CommentText(element.Comment),
+	}
+
+	if err := g.buildModelFields(ret, dt, element.Fields, "", filter, precedence, order); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (g *Helper) buildModelFields(msg *ModelMessage, dt *fdep.DepType, fields []fproto.FieldElementTag, oneof string, filter *GetFilter, precedence CommentPrecedence, order FieldOrder) error {
+	for _, fld := range g.OrderedFieldList(g.FilterFieldList(dt, fields, filter.Exclude), order) {
+		description := ""
+
+		var mfld *ModelField
+		switch xfld := fld.(type) {
+		case *fproto.FieldElement:
+			description = CommentText(g.ItemComment(dt, xfld.Name, xfld.Comment, precedence))
+
+			ftype, err := g.modelTypeName(dt, xfld.Type)
+			if err != nil {
+				return err
+			}
+
+			mfld = &ModelField{Type: xfld.Type, FullType: ftype}
+			if xfld.Required {
+				mfld.Label = "required"
+			}
+			if xfld.Repeated {
+				mfld.Label = "repeated"
+			}
+			if xfld.Optional {
+				mfld.Label = "optional"
+			}
+			mfld.Validation = g.buildModelValidation(dt, xfld.Name, xfld.Options)
+		case *fproto.MapFieldElement:
+			description = CommentText(g.ItemComment(dt, xfld.Name, xfld.Comment, precedence))
+
+			ftype, err := g.modelTypeName(dt, xfld.Type)
+			if err != nil {
+				return err
+			}
+
+			mfld = &ModelField{Type: xfld.Type, FullType: ftype, KeyType: xfld.KeyType}
+			mfld.Validation = g.buildModelValidation(dt, xfld.Name, xfld.Options)
+		case *fproto.OneOfFieldElement:
+			oneofFields := g.OrderedFieldList(g.FilterFieldList(dt, xfld.Fields, filter.Exclude), order)
+
+			moneof := &ModelOneof{
+				Name:        xfld.Name,
+				Description: CommentText(g.ItemComment(dt, xfld.Name, xfld.Comment, precedence)),
+			}
+			for _, oofld := range oneofFields {
+				moneof.Fields = append(moneof.Fields, oofld.FieldName())
+			}
+			msg.Oneofs = append(msg.Oneofs, moneof)
+
+			if err := g.buildModelFields(msg, dt, oneofFields, xfld.Name, filter, precedence, order); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+
+		mfld.Name = fld.FieldName()
+		mfld.Number = fld.FirstFieldTag()
+		mfld.Oneof = oneof
+		mfld.Description = description
+
+		msg.Fields = append(msg.Fields, mfld)
+	}
+
+	return nil
+}
+
+func (g *Helper) buildModelValidation(dt *fdep.DepType, fieldName string, options []*fproto.OptionElement) []*ModelValidationRule {
+	var ret []*ModelValidationRule
+	for _, r := range g.GetValidationRules(dt, fieldName, options) {
+		ret = append(ret, &ModelValidationRule{Rule: r.Path, Values: r.Values, Text: r.Text()})
+	}
+	return ret
+}
+
+// Get the full name of a type, or the name itself for scalars and unknown types
+func (g *Helper) modelTypeName(parentType *fdep.DepType, typeName string) (string, error) {
+	ft, err := parentType.FindType(typeName)
+	if err != nil {
+		return "", err
+	}
+	if ft == nil || ft.IsScalar() {
+		return typeName, nil
+	}
+	return ft.FullOriginalName(), nil
+}
@@ -0,0 +1,31 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RangelReale/fproto"
+)
+
+// Find an option by name. Custom option names may be passed with or without parenthesis.
+func FindOption(options []*fproto.OptionElement, name string) *fproto.OptionElement {
+	name = optionBareName(name)
+	for _, o := range options {
+		if optionBareName(o.Name) == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// Get the option value as a string, without quotes
+func OptionValue(o *fproto.OptionElement) string {
+	if o == nil {
+		return ""
+	}
+	return strings.Trim(fmt.Sprint(o.Value), `"`)
+}
+
+func optionBareName(name string) string {
+	return strings.Replace(strings.Replace(name, "(", "", -1), ")", "", -1)
+}
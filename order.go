@@ -0,0 +1,76 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/RangelReale/fproto"
+)
+
+// Order of the fields of a message, also applied to enum constants and RPCs
+type FieldOrder int
+
+const (
+	FO_DECLARATION FieldOrder = iota // declaration order
+	FO_NAME                          // field name
+	FO_TAG                           // tag number, RPCs keep the declaration order
+)
+
+// Parse a field order name (declaration, name, tag)
+func ParseFieldOrder(name string) (FieldOrder, error) {
+	switch name {
+	case "", "declaration":
+		return FO_DECLARATION, nil
+	case "name":
+		return FO_NAME, nil
+	case "tag":
+		return FO_TAG, nil
+	}
+	return FO_DECLARATION, fmt.Errorf("Unknown field order: %s", name)
+}
+
+// Get a list of fields in the order. Oneofs are kept as a single item, ordered
+// by their name or first tag, so their members stay grouped.
+func (g *Helper) OrderedFieldList(fields []fproto.FieldElementTag, order FieldOrder) []fproto.FieldElementTag {
+	if order == FO_DECLARATION {
+		return fields
+	}
+
+	ret := append([]fproto.FieldElementTag{}, fields...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		if order == FO_NAME {
+			return ret[i].FieldName() < ret[j].FieldName()
+		}
+		return ret[i].FirstFieldTag() < ret[j].FirstFieldTag()
+	})
+	return ret
+}
+
+// Get a list of enum constants in the order
+func (g *Helper) OrderedEnumConstantList(constants []*fproto.EnumConstantElement, order FieldOrder) []*fproto.EnumConstantElement {
+	if order == FO_DECLARATION {
+		return constants
+	}
+
+	ret := append([]*fproto.EnumConstantElement{}, constants...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		if order == FO_NAME {
+			return ret[i].Name < ret[j].Name
+		}
+		return ret[i].Tag < ret[j].Tag
+	})
+	return ret
+}
+
+// Get a list of RPCs in the order, RPCs have no tag so FO_TAG keeps the declaration order
+func (g *Helper) OrderedRPCList(rpcs []*fproto.RPCElement, order FieldOrder) []*fproto.RPCElement {
+	if order != FO_NAME {
+		return rpcs
+	}
+
+	ret := append([]*fproto.RPCElement{}, rpcs...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
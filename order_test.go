@@ -0,0 +1,41 @@
+package fproto_doc
+
+import (
+	"testing"
+
+	"github.com/RangelReale/fproto"
+)
+
+func TestOrderedFieldList(t *testing.T) {
+	// the duplicated names and tags must be kept, in their declaration order
+	fields := []fproto.FieldElementTag{
+		&fproto.FieldElement{Name: "c", Tag: 2},
+		&fproto.FieldElement{Name: "a", Tag: 3},
+		&fproto.FieldElement{Name: "b", Tag: 1},
+		&fproto.FieldElement{Name: "a", Tag: 2},
+	}
+
+	tests := []struct {
+		order FieldOrder
+		want  []int // indexes of the fields
+	}{
+		{FO_DECLARATION, []int{0, 1, 2, 3}},
+		{FO_NAME, []int{1, 3, 2, 0}},
+		{FO_TAG, []int{2, 0, 3, 1}},
+	}
+
+	g := &Helper{}
+	for _, tt := range tests {
+		got := g.OrderedFieldList(fields, tt.order)
+		if len(got) != len(tt.want) {
+			t.Errorf("order %d: got %d fields, want %d", tt.order, len(got), len(tt.want))
+			continue
+		}
+		for i, idx := range tt.want {
+			if got[i] != fields[idx] {
+				t.Errorf("order %d: field %d is %s/%d, want %s/%d", tt.order, i, got[i].FieldName(), got[i].FirstFieldTag(),
+					fields[idx].FieldName(), fields[idx].FirstFieldTag())
+			}
+		}
+	}
+}
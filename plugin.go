@@ -0,0 +1,141 @@
+package fproto_doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+)
+
+// External generators are executables that receive the documentation model on
+// stdin and write the generated document on stdout.
+//
+// Describe: the executable is called with the configured args, then "--describe",
+// and must write a PluginDescription as JSON on stdout.
+//
+// Generate: the executable is called with the configured args, receives a
+// PluginRequest as JSON on stdin, and must write the generated document on stdout.
+// On errors it must exit with a non-zero status, writing the error message on
+// stderr.
+
+// Version of the plugin protocol
+const PluginProtocolVersion = 1
+
+// Description of an external generator, written by the "--describe" call
+type PluginDescription struct {
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	FileName    string                 `json:"file_name,omitempty"`
+	Options     []*GeneratorOptionInfo `json:"options,omitempty"`
+}
+
+// Request sent to an external generator on stdin
+type PluginRequest struct {
+	Version   int               `json:"version"`
+	Generator string            `json:"generator"`
+	Options   map[string]string `json:"options"`
+	Model     *Model            `json:"model"`
+}
+
+// Generator that runs an external executable
+type ExternalGenerator struct {
+	Name    string
+	Command string
+	Args    []string
+	Options *GeneratorOptions
+}
+
+func (g *ExternalGenerator) Generate(dep *fdep.Dep, w io.Writer) error {
+	helper := g.Options.NewHelper(dep)
+
+	model, err := helper.BuildModel(g.Options.Filter, g.Options.CommentPrecedence, g.Options.FieldOrder)
+	if err != nil {
+		return err
+	}
+	model.Title = g.Options.Title
+
+	req, err := json.Marshal(&PluginRequest{
+		Version:   PluginProtocolVersion,
+		Generator: g.Name,
+		Options:   g.Options.Values,
+		Model:     model,
+	})
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(g.Command, g.Args...)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Error running generator %s: %v: %s", g.Name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Get the description of an external generator
+func DescribePlugin(command string, args ...string) (*PluginDescription, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command, append(append([]string{}, args...), "--describe")...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Error describing generator '%s': %v: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	ret := &PluginDescription{}
+	if err := json.Unmarshal(stdout.Bytes(), ret); err != nil {
+		return nil, fmt.Errorf("Invalid description of generator '%s': %v", command, err)
+	}
+	return ret, nil
+}
+
+// Register an external generator executable. If name is blank, the name from
+// the plugin description is used. The name can't be of a registered generator.
+func RegisterExternalGenerator(name string, command string, args ...string) error {
+	if _, exists := generators[name]; exists {
+		return fmt.Errorf("The generator '%s' of '%s' is already registered", name, command)
+	}
+
+	desc, err := DescribePlugin(command, args...)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = desc.Name
+	}
+	if name == "" {
+		return fmt.Errorf("The generator '%s' has no name", command)
+	}
+	if _, exists := generators[name]; exists {
+		return fmt.Errorf("The generator '%s' of '%s' is already registered", name, command)
+	}
+
+	fileName := desc.FileName
+	if fileName == "" {
+		fileName = name + ".out"
+	}
+
+	RegisterGenerator(&GeneratorInfo{
+		Name:        name,
+		Description: desc.Description,
+		FileName:    fileName,
+		Options:     desc.Options,
+		Factory: func(options *GeneratorOptions) (Generator, error) {
+			return &ExternalGenerator{
+				Name:    name,
+				Command: command,
+				Args:    args,
+				Options: options,
+			}, nil
+		},
+	})
+	return nil
+}
@@ -0,0 +1,55 @@
+package fproto_doc
+
+// Scalar value type, with the equivalent types of the languages
+type ScalarType struct {
+	Name   string // proto type
+	Notes  string
+	Go     string
+	Java   string
+	Python string
+	Cpp    string
+	CSharp string
+	JS     string
+	JSON   string // JSON representation
+}
+
+// Catalog of the scalar value types, in the order of the protobuf documentation
+var ScalarTypes = []*ScalarType{
+	{Name: "double", Go: "float64", Java: "double", Python: "float", Cpp: "double", CSharp: "double", JS: "number", JSON: "number"},
+	{Name: "float", Go: "float32", Java: "float", Python: "float", Cpp: "float", CSharp: "float", JS: "number", JSON: "number"},
+	{Name: "int32", Notes: "Uses variable-length encoding. Inefficient for encoding negative numbers, if the field is likely to have negative values use sint32 instead.",
+		Go: "int32", Java: "int", Python: "int", Cpp: "int32", CSharp: "int", JS: "number", JSON: "number"},
+	{Name: "int64", Notes: "Uses variable-length encoding. Inefficient for encoding negative numbers, if the field is likely to have negative values use sint64 instead.",
+		Go: "int64", Java: "long", Python: "int", Cpp: "int64", CSharp: "long", JS: "bigint", JSON: "string"},
+	{Name: "uint32", Notes: "Uses variable-length encoding.",
+		Go: "uint32", Java: "int", Python: "int", Cpp: "uint32", CSharp: "uint", JS: "number", JSON: "number"},
+	{Name: "uint64", Notes: "Uses variable-length encoding.",
+		Go: "uint64", Java: "long", Python: "int", Cpp: "uint64", CSharp: "ulong", JS: "bigint", JSON: "string"},
+	{Name: "sint32", Notes: "Uses variable-length encoding. Signed int value, encodes negative numbers more efficiently than int32.",
+		Go: "int32", Java: "int", Python: "int", Cpp: "int32", CSharp: "int", JS: "number", JSON: "number"},
+	{Name: "sint64", Notes: "Uses variable-length encoding. Signed int value, encodes negative numbers more efficiently than int64.",
+		Go: "int64", Java: "long", Python: "int", Cpp: "int64", CSharp: "long", JS: "bigint", JSON: "string"},
+	{Name: "fixed32", Notes: "Always four bytes. More efficient than uint32 if values are often greater than 2^28.",
+		Go: "uint32", Java: "int", Python: "int", Cpp: "uint32", CSharp: "uint", JS: "number", JSON: "number"},
+	{Name: "fixed64", Notes: "Always eight bytes. More efficient than uint64 if values are often greater than 2^56.",
+		Go: "uint64", Java: "long", Python: "int", Cpp: "uint64", CSharp: "ulong", JS: "bigint", JSON: "string"},
+	{Name: "sfixed32", Notes: "Always four bytes.",
+		Go: "int32", Java: "int", Python: "int", Cpp: "int32", CSharp: "int", JS: "number", JSON: "number"},
+	{Name: "sfixed64", Notes: "Always eight bytes.",
+		Go: "int64", Java: "long", Python: "int", Cpp: "int64", CSharp: "long", JS: "bigint", JSON: "string"},
+	{Name: "bool", Go: "bool", Java: "boolean", Python: "bool", Cpp: "bool", CSharp: "bool", JS: "boolean", JSON: "true or false"},
+	{Name: "string", Notes: "Must contain UTF-8 encoded or 7-bit ASCII text, and can't be longer than 2^32.",
+		Go: "string", Java: "String", Python: "str", Cpp: "string", CSharp: "string", JS: "string", JSON: "string"},
+	{Name: "bytes", Notes: "May contain any arbitrary sequence of bytes no longer than 2^32.",
+		Go: "[]byte", Java: "ByteString", Python: "bytes", Cpp: "string", CSharp: "ByteString", JS: "Uint8Array", JSON: "base64 string"},
+}
+
+// Find a scalar type by name, or nil if not found
+func FindScalarType(name string) *ScalarType {
+	for _, t := range ScalarTypes {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
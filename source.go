@@ -0,0 +1,351 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/RangelReale/fdep"
+)
+
+// Loads the source text of a proto file from its dependency file path
+type SourceLoader interface {
+	LoadSource(filePath string) ([]byte, error)
+}
+
+// Source root directory, using the same convention as fdep.AddPathWithRoot
+type SourceRoot struct {
+	Dir  string // directory on disk
+	Root string // path prefix of the files inside fdep
+}
+
+// Source loader that reads files from a list of root directories
+type DirSourceLoader struct {
+	Roots []SourceRoot
+}
+
+func NewDirSourceLoader(roots []SourceRoot) *DirSourceLoader {
+	return &DirSourceLoader{
+		Roots: roots,
+	}
+}
+
+func (sl *DirSourceLoader) LoadSource(filePath string) ([]byte, error) {
+	for _, r := range sl.Roots {
+		fp := filePath
+		if r.Root != "" {
+			prefix := strings.TrimSuffix(filepath.ToSlash(r.Root), "/") + "/"
+			if !strings.HasPrefix(fp, prefix) {
+				continue
+			}
+			fp = strings.TrimPrefix(fp, prefix)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(r.Dir, filepath.FromSlash(fp)))
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("Source file not found: %s", filePath)
+}
+
+// Declaration found in the proto source
+type SourceDecl struct {
+	Name            string   // name relative to the package, like "Message.field"
+	StartLine       int      // first line of the declaration (1-based)
+	EndLine         int      // last line of the declaration (1-based)
+	TrailingComment []string // comment on the same line after the declaration
+}
+
+// Declarations of a proto source file
+type SourceFile struct {
+	Lines []string
+	Decls map[string]*SourceDecl
+}
+
+// Find a declaration by its name relative to the package
+func (sf *SourceFile) FindDecl(name string) *SourceDecl {
+	if sf == nil {
+		return nil
+	}
+	return sf.Decls[name]
+}
+
+// Source index, loads and caches source files on demand
+type SourceIndex struct {
+	loader SourceLoader
+	files  map[string]*SourceFile
+}
+
+func NewSourceIndex(loader SourceLoader) *SourceIndex {
+	return &SourceIndex{
+		loader: loader,
+		files:  make(map[string]*SourceFile),
+	}
+}
+
+// Get the parsed source file, or nil if the source is not available
+func (si *SourceIndex) GetFile(filePath string) *SourceFile {
+	if si == nil || si.loader == nil {
+		return nil
+	}
+
+	if sf, ok := si.files[filePath]; ok {
+		return sf
+	}
+
+	var sf *SourceFile
+	if data, err := si.loader.LoadSource(filePath); err == nil {
+		sf = ParseSource(string(data))
+	}
+	si.files[filePath] = sf
+	return sf
+}
+
+// Get the parsed source file, or nil if no source loader was set or the source is not available
+func (g *Helper) GetSourceFile(filePath string) *SourceFile {
+	return g.source.GetFile(filePath)
+}
+
+// Get the source declaration of a type, or of a declaration inside it if name isn't blank.
+// Returns nil if the source is not available.
+func (g *Helper) GetSourceDecl(dt *fdep.DepType, name string) *SourceDecl {
+	if dt.DepFile == nil {
+		return nil
+	}
+
+	declName := dt.Name
+	if name != "" {
+		declName += "." + name
+	}
+	return g.source.GetFile(dt.DepFile.FilePath).FindDecl(declName)
+}
+
+// Parse a proto source, collecting the positions of the declarations.
+// This is a lightweight scanner, the source is expected to be valid as it was
+// already parsed by fproto.
+func ParseSource(source string) *SourceFile {
+	ret := &SourceFile{
+		Lines: strings.Split(strings.Replace(source, "\r\n", "\n", -1), "\n"),
+		Decls: make(map[string]*SourceDecl),
+	}
+
+	type scope struct {
+		kind string
+		name string
+		decl *SourceDecl
+	}
+
+	var scopes []*scope
+	var stmt []srcToken
+	var last *SourceDecl
+	agg := 0 // depth of the aggregated option values, which are part of the statement
+
+	scopeName := func(name string) string {
+		var names []string
+		for _, s := range scopes {
+			if s.name != "" {
+				names = append(names, s.name)
+			}
+		}
+		return strings.Join(append(names, name), ".")
+	}
+	curKind := func() string {
+		if len(scopes) == 0 {
+			return ""
+		}
+		return scopes[len(scopes)-1].kind
+	}
+	// fields inside oneofs belong to the message
+	fieldScopeName := func(name string) string {
+		var names []string
+		for _, s := range scopes {
+			if s.name != "" && s.kind != "oneof" {
+				names = append(names, s.name)
+			}
+		}
+		return strings.Join(append(names, name), ".")
+	}
+
+	for _, tk := range scanSource(source) {
+		if tk.comment {
+			// comment on the same line of the end of the last declaration
+			if last != nil && tk.line == last.EndLine && last.TrailingComment == nil {
+				last.TrailingComment = tk.lines
+			}
+			continue
+		}
+
+		last = nil
+
+		// aggregated option values, like "[(validate.rules).string = {min_len: 1}]"
+		if agg > 0 || (tk.text == "{" && len(stmt) > 0 && (stmt[len(stmt)-1].text == "=" || stmt[len(stmt)-1].text == ":")) {
+			switch tk.text {
+			case "{":
+				agg++
+			case "}":
+				agg--
+			}
+			stmt = append(stmt, tk)
+			continue
+		}
+
+		switch tk.text {
+		case "{":
+			sc := &scope{}
+			if len(stmt) >= 2 {
+				switch stmt[0].text {
+				case "message", "enum", "service", "oneof", "rpc":
+					sc.kind = stmt[0].text
+					sc.name = stmt[1].text
+					sc.decl = &SourceDecl{Name: scopeName(sc.name), StartLine: stmt[0].line}
+					if sc.kind == "oneof" {
+						sc.decl.Name = fieldScopeName(sc.name)
+					}
+				}
+			}
+			scopes = append(scopes, sc)
+			stmt = nil
+		case "}":
+			if len(scopes) > 0 {
+				sc := scopes[len(scopes)-1]
+				scopes = scopes[:len(scopes)-1]
+				if sc.decl != nil {
+					sc.decl.EndLine = tk.line
+					ret.Decls[sc.decl.Name] = sc.decl
+					last = sc.decl
+				}
+			}
+			stmt = nil
+		case ";":
+			if len(stmt) > 0 {
+				if decl := parseSourceStmt(stmt, curKind()); decl != nil {
+					if curKind() == "message" || curKind() == "oneof" {
+						decl.Name = fieldScopeName(decl.Name)
+					} else {
+						decl.Name = scopeName(decl.Name)
+					}
+					decl.EndLine = tk.line
+					ret.Decls[decl.Name] = decl
+					last = decl
+				}
+			}
+			stmt = nil
+		default:
+			stmt = append(stmt, tk)
+		}
+	}
+
+	return ret
+}
+
+// Parse a statement ended by ";" in the passed scope kind
+func parseSourceStmt(stmt []srcToken, kind string) *SourceDecl {
+	switch stmt[0].text {
+	case "option", "reserved", "extensions", "syntax", "package", "import":
+		return nil
+	case "rpc":
+		if kind == "service" && len(stmt) > 1 {
+			return &SourceDecl{Name: stmt[1].text, StartLine: stmt[0].line}
+		}
+		return nil
+	}
+
+	switch kind {
+	case "message", "oneof", "enum":
+		// name = tag [options]
+		for i := 1; i < len(stmt)-1; i++ {
+			if stmt[i].text == "=" {
+				return &SourceDecl{Name: stmt[i-1].text, StartLine: stmt[0].line}
+			}
+		}
+	}
+	return nil
+}
+
+// Source token
+type srcToken struct {
+	text    string
+	line    int
+	comment bool
+	lines   []string // comment lines
+}
+
+// Splits the source in tokens, keeping only identifiers, punctuation and comments.
+func scanSource(source string) []srcToken {
+	var ret []srcToken
+
+	src := []rune(source)
+	line := 1
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+		case unicode.IsSpace(c):
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			start := i + 2
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			ret = append(ret, srcToken{line: line, comment: true, lines: []string{cleanLineComment(string(src[start:i]))}})
+			i--
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			start, sline := i+2, line
+			i += 2
+			for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			end := i
+			if end >= len(src) {
+				end = len(src)
+			}
+			ret = append(ret, srcToken{line: sline, comment: true, lines: cleanBlockComment(string(src[start:end]))})
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\n' {
+					line++
+				}
+			}
+			end := i + 1
+			if end > len(src) {
+				end = len(src)
+			}
+			ret = append(ret, srcToken{text: string(src[start:end]), line: line})
+		case c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || unicode.IsLetter(src[i]) || unicode.IsDigit(src[i])) {
+				i++
+			}
+			ret = append(ret, srcToken{text: string(src[start:i]), line: line})
+			i--
+		default:
+			ret = append(ret, srcToken{text: string(c), line: line})
+		}
+	}
+
+	return ret
+}
+
+func cleanLineComment(s string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(s, "/"), " ")
+}
+
+func cleanBlockComment(s string) []string {
+	var ret []string
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "*")
+		ret = append(ret, strings.TrimPrefix(l, " "))
+	}
+	return ret
+}
@@ -0,0 +1,181 @@
+package fproto_doc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		decls  map[string]*SourceDecl
+	}{
+		{
+			name: "trailing comments",
+			source: `syntax = "proto3";
+package test;
+
+message Msg {
+  string name = 1; // the name
+  int32 age = 2;   /* the age */
+  // leading comment of id
+  int64 id = 3;
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":      {Name: "Msg", StartLine: 4, EndLine: 9},
+				"Msg.name": {Name: "Msg.name", StartLine: 5, EndLine: 5, TrailingComment: []string{"the name"}},
+				"Msg.age":  {Name: "Msg.age", StartLine: 6, EndLine: 6, TrailingComment: []string{"the age"}},
+				"Msg.id":   {Name: "Msg.id", StartLine: 8, EndLine: 8},
+			},
+		},
+		{
+			name: "leading comments are not trailing",
+			source: `message Msg {
+  // leading of a
+  string a = 1;
+  /*
+   * leading of b
+   */
+  string b = 2;
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":   {Name: "Msg", StartLine: 1, EndLine: 8},
+				"Msg.a": {Name: "Msg.a", StartLine: 3, EndLine: 3},
+				"Msg.b": {Name: "Msg.b", StartLine: 7, EndLine: 7},
+			},
+		},
+		{
+			name: "nested messages and enums",
+			source: `message Outer {
+  message Inner {
+    string value = 1; // inner value
+  }
+  enum Kind {
+    KIND_UNKNOWN = 0; // unknown
+    KIND_A = 1;
+  }
+  Inner inner = 1;
+}`,
+			decls: map[string]*SourceDecl{
+				"Outer":                   {Name: "Outer", StartLine: 1, EndLine: 10},
+				"Outer.Inner":             {Name: "Outer.Inner", StartLine: 2, EndLine: 4},
+				"Outer.Inner.value":       {Name: "Outer.Inner.value", StartLine: 3, EndLine: 3, TrailingComment: []string{"inner value"}},
+				"Outer.Kind":              {Name: "Outer.Kind", StartLine: 5, EndLine: 8},
+				"Outer.Kind.KIND_UNKNOWN": {Name: "Outer.Kind.KIND_UNKNOWN", StartLine: 6, EndLine: 6, TrailingComment: []string{"unknown"}},
+				"Outer.Kind.KIND_A":       {Name: "Outer.Kind.KIND_A", StartLine: 7, EndLine: 7},
+				"Outer.inner":             {Name: "Outer.inner", StartLine: 9, EndLine: 9},
+			},
+		},
+		{
+			name: "oneof fields belong to the message",
+			source: `message Msg {
+  oneof value {
+    string text = 1; // text value
+    int32 number = 2;
+  }
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":        {Name: "Msg", StartLine: 1, EndLine: 6},
+				"Msg.value":  {Name: "Msg.value", StartLine: 2, EndLine: 5},
+				"Msg.text":   {Name: "Msg.text", StartLine: 3, EndLine: 3, TrailingComment: []string{"text value"}},
+				"Msg.number": {Name: "Msg.number", StartLine: 4, EndLine: 4},
+			},
+		},
+		{
+			name: "rpcs with option blocks",
+			source: `service Svc {
+  rpc Get(GetRequest) returns (GetResponse) {
+    option (google.api.http) = {
+      get: "/v1/{name=items/*}"
+    };
+  } // get an item
+  rpc List(ListRequest) returns (stream ListResponse); // list the items
+}`,
+			decls: map[string]*SourceDecl{
+				"Svc":      {Name: "Svc", StartLine: 1, EndLine: 8},
+				"Svc.Get":  {Name: "Svc.Get", StartLine: 2, EndLine: 6, TrailingComment: []string{"get an item"}},
+				"Svc.List": {Name: "Svc.List", StartLine: 7, EndLine: 7, TrailingComment: []string{"list the items"}},
+			},
+		},
+		{
+			name: "aggregate options",
+			source: `message Msg {
+  option (my.msg) = { a: 1 b: { c: "}" } };
+  string name = 1 [(validate.rules).string = {
+    min_len: 1,
+    max_len: 10
+  }]; // the name
+  string other = 2;
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":       {Name: "Msg", StartLine: 1, EndLine: 8},
+				"Msg.name":  {Name: "Msg.name", StartLine: 3, EndLine: 6, TrailingComment: []string{"the name"}},
+				"Msg.other": {Name: "Msg.other", StartLine: 7, EndLine: 7},
+			},
+		},
+		{
+			name: "strings with comment and brace characters",
+			source: `message Msg {
+  string url = 1 [json_name = "http://host/{x}"]; // the url
+  string quote = 2 [json_name = 'it\'s // not a comment {'];
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":       {Name: "Msg", StartLine: 1, EndLine: 4},
+				"Msg.url":   {Name: "Msg.url", StartLine: 2, EndLine: 2, TrailingComment: []string{"the url"}},
+				"Msg.quote": {Name: "Msg.quote", StartLine: 3, EndLine: 3},
+			},
+		},
+		{
+			name: "block comments spanning lines",
+			source: `/* file
+   comment { with brace */
+message Msg {
+  string a = 1; /* first
+  second */
+  string b = 2;
+}`,
+			decls: map[string]*SourceDecl{
+				"Msg":   {Name: "Msg", StartLine: 3, EndLine: 7},
+				"Msg.a": {Name: "Msg.a", StartLine: 4, EndLine: 4, TrailingComment: []string{"first", "second"}},
+				"Msg.b": {Name: "Msg.b", StartLine: 6, EndLine: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := ParseSource(tt.source)
+			if !reflect.DeepEqual(sf.Decls, tt.decls) {
+				for name, decl := range sf.Decls {
+					t.Logf("got %s: %+v", name, decl)
+				}
+				t.Errorf("declarations don't match")
+			}
+		})
+	}
+}
+
+func TestScanSource(t *testing.T) {
+	tests := []struct {
+		source string
+		texts  []string
+	}{
+		{`option (a.b).c = "x // y";`, []string{"option", "(", "a.b", ")", ".c", "=", `"x // y"`, ";"}},
+		{`int32 a = -1; // c`, []string{"int32", "a", "=", "-", "1", ";"}},
+		{`/* a { */ b`, []string{"b"}},
+		{`"esc\"aped" 'q'`, []string{`"esc\"aped"`, `'q'`}},
+	}
+
+	for _, tt := range tests {
+		var texts []string
+		for _, tk := range scanSource(tt.source) {
+			if !tk.comment {
+				texts = append(texts, tk.text)
+			}
+		}
+		if !reflect.DeepEqual(texts, tt.texts) {
+			t.Errorf("scanSource(%q) = %q, want %q", tt.source, texts, tt.texts)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package fproto_doc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RangelReale/fdep"
+	"github.com/RangelReale/fproto"
+)
+
+// Names of the field options with validation rules
+const (
+	ValidateRulesOption = "validate.rules"     // protoc-gen-validate
+	BufValidateOption   = "buf.validate.field" // buf validate (protovalidate)
+)
+
+// Validation rule of a field
+type ValidationRule struct {
+	Path   string   // rule path inside the option, like "string.min_len" or "repeated.items.string.pattern"
+	Values []string // rule values, more than one for lists like "in"
+}
+
+// Texts of the rules by rule name, "%s" is replaced by the values.
+// Rules without "%s" are flags, only shown if true.
+var validationRuleTexts = map[string]string{
+	"const":            "= %s",
+	"lt":               "< %s",
+	"lte":              "<= %s",
+	"gt":               "> %s",
+	"gte":              ">= %s",
+	"in":               "in [%s]",
+	"not_in":           "not in [%s]",
+	"len":              "length %s",
+	"min_len":          "min length %s",
+	"max_len":          "max length %s",
+	"len_bytes":        "length %s bytes",
+	"min_bytes":        "min %s bytes",
+	"max_bytes":        "max %s bytes",
+	"pattern":          "matches /%s/",
+	"prefix":           "prefix %s",
+	"suffix":           "suffix %s",
+	"contains":         "contains %s",
+	"not_contains":     "not contains %s",
+	"min_items":        "min %s items",
+	"max_items":        "max %s items",
+	"min_pairs":        "min %s entries",
+	"max_pairs":        "max %s entries",
+	"within":           "within %s of now",
+	"well_known_regex": "%s",
+	"cel":              "%s",
+	"required":         "required",
+	"defined_only":     "defined values only",
+	"unique":           "unique items",
+	"lt_now":           "before now",
+	"gt_now":           "after now",
+	"email":            "email",
+	"hostname":         "hostname",
+	"ip":               "IP address",
+	"ipv4":             "IPv4 address",
+	"ipv6":             "IPv6 address",
+	"uri":              "URI",
+	"uri_ref":          "URI reference",
+	"address":          "hostname or IP address",
+	"uuid":             "UUID",
+	"skip":             "not validated",
+	"ignore_empty":     "ignored if empty",
+}
+
+// Rules that have string values, which are shown quoted
+var validationStringRules = map[string]bool{
+	"const": true, "in": true, "not_in": true, "prefix": true, "suffix": true, "contains": true, "not_contains": true,
+}
+
+// Human-readable text of the rule, like "min length 1" or "items: matches /^[a-z]+$/".
+// Returns a blank string for flags that are false.
+func (r *ValidationRule) Text() string {
+	parts := strings.Split(r.Path, ".")
+
+	// rules of the items of repeated fields and of the keys and values of maps
+	prefix := ""
+	for len(parts) > 2 && ((parts[0] == "repeated" && parts[1] == "items") || (parts[0] == "map" && (parts[1] == "keys" || parts[1] == "values"))) {
+		prefix += parts[1] + ": "
+		parts = parts[2:]
+	}
+
+	rule_type := ""
+	if len(parts) > 1 {
+		rule_type = parts[0]
+		parts = parts[1:]
+	}
+	rule := strings.Join(parts, ".")
+
+	values := r.Values
+	if (rule_type == "string" || rule_type == "bytes") && validationStringRules[rule] {
+		values = make([]string, len(r.Values))
+		for vidx, v := range r.Values {
+			values[vidx] = strconv.Quote(v)
+		}
+	}
+
+	format, ok := validationRuleTexts[rule]
+	switch {
+	case !ok:
+		return prefix + rule + ": " + strings.Join(values, ", ")
+	case !strings.Contains(format, "%s"):
+		if len(values) > 0 && values[0] != "true" {
+			return ""
+		}
+		return prefix + format
+	}
+	return prefix + fmt.Sprintf(format, strings.Join(values, ", "))
+}
+
+// Get the validation rules of a field, from the protoc-gen-validate and buf validate options.
+// The options are read from the proto source if available, as the parser doesn't
+// keep nested aggregated values.
+func (g *Helper) GetValidationRules(dt *fdep.DepType, fieldName string, options []*fproto.OptionElement) []*ValidationRule {
+	var ret []*ValidationRule
+
+	// read from the source
+	if decl := g.GetSourceDecl(dt, fieldName); decl != nil {
+		sf := g.GetSourceFile(dt.DepFile.FilePath)
+		if decl.StartLine >= 1 && decl.EndLine <= len(sf.Lines) {
+			for _, opt := range findSourceFieldOptions(sf.Lines[decl.StartLine-1:decl.EndLine], ValidateRulesOption, BufValidateOption) {
+				ret = addValidationRules(ret, "", opt)
+			}
+			return ret
+		}
+	}
+
+	// option values
+	for _, o := range options {
+		name := optionBareName(o.Name)
+		for _, vo := range []string{ValidateRulesOption, BufValidateOption} {
+			if name != vo && !strings.HasPrefix(name, vo+".") {
+				continue
+			}
+			path := strings.TrimPrefix(strings.TrimPrefix(name, vo), ".")
+
+			if len(o.AggregatedValues) > 0 {
+				// sorted for a stable order of the rules
+				var anames []string
+				for aname := range o.AggregatedValues {
+					anames = append(anames, aname)
+				}
+				sort.Strings(anames)
+
+				var fields []*textField
+				for _, aname := range anames {
+					fields = append(fields, &textField{name: aname, value: strings.Trim(fmt.Sprint(o.AggregatedValues[aname]), `"`)})
+				}
+				ret = addValidationRules(ret, path, fields)
+			} else if path != "" {
+				ret = addValidationRules(ret, "", []*textField{{name: path, value: OptionValue(o)}})
+			}
+		}
+	}
+	return ret
+}
+
+// Adds the rules of the option fields, merging repeated rules like "in"
+func addValidationRules(rules []*ValidationRule, prefix string, fields []*textField) []*ValidationRule {
+	for _, f := range fields {
+		path := f.name
+		if prefix != "" {
+			path = prefix + "." + f.name
+		}
+
+		parent, rule := "", path
+		if pos := strings.LastIndex(path, "."); pos >= 0 {
+			parent, rule = path[:pos], path[pos+1:]
+		}
+
+		if f.message != nil && !isValidationValueMessage(rule) {
+			rules = addValidationRules(rules, path, f.message)
+			continue
+		}
+
+		value := f.value
+		if f.message != nil {
+			value = validationMessageValue(rule, parent, f.message)
+		}
+
+		found := false
+		for _, r := range rules {
+			if r.Path == path {
+				r.Values = append(r.Values, value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			rules = append(rules, &ValidationRule{Path: path, Values: []string{value}})
+		}
+	}
+	return rules
+}
+
+// Checks if the rule value is a message, like the limits of durations and the CEL expressions
+func isValidationValueMessage(name string) bool {
+	switch name {
+	case "const", "lt", "lte", "gt", "gte", "in", "not_in", "within", "cel":
+		return true
+	}
+	return false
+}
+
+// Text of a message rule value
+func validationMessageValue(name string, parent string, fields []*textField) string {
+	values := make(map[string]string)
+	for _, f := range fields {
+		values[f.name] = f.value
+	}
+
+	// CEL expressions are shown by their message
+	if name == "cel" {
+		if values["message"] != "" {
+			return values["message"]
+		}
+		return values["expression"]
+	}
+
+	// durations and timestamps
+	if strings.HasSuffix(parent, "duration") || strings.HasSuffix(parent, "timestamp") {
+		seconds, err := strconv.ParseInt(values["seconds"], 10, 64)
+		if values["seconds"] == "" {
+			seconds, err = 0, nil
+		}
+		nanos, nerr := strconv.ParseInt(values["nanos"], 10, 64)
+		if values["nanos"] == "" {
+			nanos, nerr = 0, nil
+		}
+		if err == nil && nerr == nil && len(values) <= 2 {
+			if strings.HasSuffix(parent, "duration") {
+				return strconv.FormatFloat(float64(seconds)+float64(nanos)/1e9, 'f', -1, 64) + "s"
+			}
+			return strconv.FormatInt(seconds, 10) + "s since the epoch"
+		}
+	}
+
+	var ret []string
+	for _, f := range fields {
+		ret = append(ret, f.name+": "+f.value)
+	}
+	return "{" + strings.Join(ret, ", ") + "}"
+}
+
+// Find the field options in the source lines and parse their values, like
+// "[(validate.rules).string.min_len = 1]" or "[(validate.rules).string = {min_len: 1}]".
+// The returned fields include the option path, like "string".
+func findSourceFieldOptions(lines []string, names ...string) [][]*textField {
+	var tks []srcToken
+	for _, tk := range scanSource(strings.Join(lines, "\n")) {
+		if !tk.comment {
+			tks = append(tks, tk)
+		}
+	}
+
+	var ret [][]*textField
+	for i := 0; i+3 < len(tks); i++ {
+		if tks[i].text != "(" || tks[i+2].text != ")" {
+			continue
+		}
+		found := false
+		for _, name := range names {
+			if tks[i+1].text == name {
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		pos := i + 3
+		path := ""
+		if strings.HasPrefix(tks[pos].text, ".") {
+			path = strings.TrimPrefix(tks[pos].text, ".")
+			pos++
+		}
+		if pos+1 >= len(tks) || tks[pos].text != "=" {
+			continue
+		}
+		pos++
+
+		f := &textField{name: path}
+		parseTextValue(tks, &pos, f)
+		if path == "" {
+			// the whole option as a message
+			ret = append(ret, f.message)
+		} else {
+			ret = append(ret, []*textField{f})
+		}
+		i = pos - 1
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+package fproto_doc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidationRulesFromSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		texts  []string
+	}{
+		{
+			name:   "aggregated string rules",
+			source: `string name = 1 [(validate.rules).string = {min_len: 1, max_len: 10, pattern: "^[a-z]+$", in: ["a", "b"]}];`,
+			texts:  []string{"min length 1", "max length 10", "matches /^[a-z]+$/", `in ["a", "b"]`},
+		},
+		{
+			name: "multiple options and negative numbers",
+			source: `int32 count = 1 [
+  (validate.rules).int32.gte = -1,
+  (validate.rules).int32.lt = 100 // comment
+];`,
+			texts: []string{">= -1", "< 100"},
+		},
+		{
+			name:   "quoted strings with escapes",
+			source: `string code = 1 [(validate.rules).string = {pattern: "^\\d+\"$", prefix: 'it\'s'}];`,
+			texts:  []string{`matches /^\d+"$/`, `prefix "it's"`},
+		},
+		{
+			name:   "repeated items",
+			source: `repeated string emails = 1 [(validate.rules).repeated = {min_items: 1, unique: true, items: {string: {email: true}}}];`,
+			texts:  []string{"min 1 items", "unique items", "items: email"},
+		},
+		{
+			name:   "map keys and values",
+			source: `map<string, int32> counts = 1 [(validate.rules).map = {keys: {string: {min_len: 2}}, values: {int32: {gt: 0}}}];`,
+			texts:  []string{"keys: min length 2", "values: > 0"},
+		},
+		{
+			name:   "duration and timestamp messages",
+			source: `google.protobuf.Duration ttl = 1 [(validate.rules).duration = {lt: {seconds: 1, nanos: 500000000}, required: true}, (validate.rules).timestamp.gt = {seconds: 10}];`,
+			texts:  []string{"< 1.5s", "required", "> 10s since the epoch"},
+		},
+		{
+			name:   "false flags",
+			source: `string email = 1 [(validate.rules).string.email = false];`,
+			texts:  []string{""},
+		},
+		{
+			name:   "buf validate",
+			source: `int32 even = 1 [(buf.validate.field).cel = {id: "even", message: "must be even", expression: "this % 2 == 0"}, (buf.validate.field).required = true];`,
+			texts:  []string{"must be even", "required"},
+		},
+		{
+			name:   "other options",
+			source: `string name = 1 [json_name = "n", (other.rules).string.min_len = 1];`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []*ValidationRule
+			for _, opt := range findSourceFieldOptions(strings.Split(tt.source, "\n"), ValidateRulesOption, BufValidateOption) {
+				rules = addValidationRules(rules, "", opt)
+			}
+
+			var texts []string
+			for _, r := range rules {
+				texts = append(texts, r.Text())
+			}
+			if !reflect.DeepEqual(texts, tt.texts) {
+				t.Errorf("got %q, want %q", texts, tt.texts)
+			}
+		})
+	}
+}
+
+func TestValidationRuleText(t *testing.T) {
+	tests := []struct {
+		rule *ValidationRule
+		text string
+	}{
+		{&ValidationRule{Path: "string.min_len", Values: []string{"1"}}, "min length 1"},
+		{&ValidationRule{Path: "string.in", Values: []string{"a", "b"}}, `in ["a", "b"]`},
+		{&ValidationRule{Path: "int32.in", Values: []string{"1", "2"}}, "in [1, 2]"},
+		{&ValidationRule{Path: "repeated.items.repeated.items.string.uuid", Values: []string{"true"}}, "items: items: UUID"},
+		{&ValidationRule{Path: "message.required", Values: []string{"false"}}, ""},
+		{&ValidationRule{Path: "string.unknown_rule", Values: []string{"x"}}, "unknown_rule: x"},
+	}
+
+	for _, tt := range tests {
+		if text := tt.rule.Text(); text != tt.text {
+			t.Errorf("%s: got %q, want %q", tt.rule.Path, text, tt.text)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package fproto_doc
+
+import (
+	"strings"
+)
+
+// Well-known type of the protobuf library
+type WellKnownType struct {
+	Name        string // full name, like "google.protobuf.Timestamp"
+	Description string
+	JSON        string   // JSON representation
+	JSONType    JSONType // JSON mapping, for the schemas
+	Go          string   // Go field type, like "*timestamppb.Timestamp"
+	Java        string   // Java class, like "com.google.protobuf.Timestamp"
+	TS          string   // TypeScript type of protoc-gen-es, the wrappers are unboxed
+	URL         string   // reference documentation
+}
+
+const wellKnownTypesURL = "https://protobuf.dev/reference/protobuf/google.protobuf/"
+
+// Catalog of the well-known types, sorted by name
+var WellKnownTypes = []*WellKnownType{
+	{Name: "google.protobuf.Any", Description: "Arbitrary message, with the URL of its type.",
+		JSON:     `object with the fields of the message and "@type", like {"@type": "type.googleapis.com/pkg.Message", ...}`,
+		JSONType: JSONType{Type: "object"},
+		Go:       "*anypb.Any", Java: "com.google.protobuf.Any", TS: "Any"},
+	{Name: "google.protobuf.BoolValue", Description: "Wrapper for bool, to tell unset from false.",
+		JSON:     "true, false or null",
+		JSONType: JSONType{Type: "boolean", Nullable: true},
+		Go:       "*wrapperspb.BoolValue", Java: "com.google.protobuf.BoolValue", TS: "boolean"},
+	{Name: "google.protobuf.BytesValue", Description: "Wrapper for bytes, to tell unset from empty.",
+		JSON:     "base64 string or null",
+		JSONType: JSONType{Type: "string", Format: "byte", Nullable: true},
+		Go:       "*wrapperspb.BytesValue", Java: "com.google.protobuf.BytesValue", TS: "Uint8Array"},
+	{Name: "google.protobuf.DoubleValue", Description: "Wrapper for double, to tell unset from 0.",
+		JSON:     "number or null",
+		JSONType: JSONType{Type: "number", Format: "double", Nullable: true},
+		Go:       "*wrapperspb.DoubleValue", Java: "com.google.protobuf.DoubleValue", TS: "number"},
+	{Name: "google.protobuf.Duration", Description: "Signed span of time with nanosecond precision, independent of any calendar.",
+		JSON:     `string with the seconds and the "s" suffix, like "1.5s"`,
+		JSONType: JSONType{Type: "string", Format: "duration"},
+		Go:       "*durationpb.Duration", Java: "com.google.protobuf.Duration", TS: "Duration"},
+	{Name: "google.protobuf.Empty", Description: "Empty message, for RPCs without request or response data.",
+		JSON:     "{}",
+		JSONType: JSONType{Type: "object"},
+		Go:       "*emptypb.Empty", Java: "com.google.protobuf.Empty", TS: "Empty"},
+	{Name: "google.protobuf.FieldMask", Description: "Set of field paths, for partial reads and updates.",
+		JSON:     `string with the lowerCamelCase paths separated by commas, like "user.displayName,photo"`,
+		JSONType: JSONType{Type: "string", Format: "field-mask"},
+		Go:       "*fieldmaskpb.FieldMask", Java: "com.google.protobuf.FieldMask", TS: "FieldMask"},
+	{Name: "google.protobuf.FloatValue", Description: "Wrapper for float, to tell unset from 0.",
+		JSON:     "number or null",
+		JSONType: JSONType{Type: "number", Format: "float", Nullable: true},
+		Go:       "*wrapperspb.FloatValue", Java: "com.google.protobuf.FloatValue", TS: "number"},
+	{Name: "google.protobuf.Int32Value", Description: "Wrapper for int32, to tell unset from 0.",
+		JSON:     "number or null",
+		JSONType: JSONType{Type: "integer", Format: "int32", Nullable: true},
+		Go:       "*wrapperspb.Int32Value", Java: "com.google.protobuf.Int32Value", TS: "number"},
+	{Name: "google.protobuf.Int64Value", Description: "Wrapper for int64, to tell unset from 0.",
+		JSON:     "string or null",
+		JSONType: JSONType{Type: "string", Format: "int64", Nullable: true},
+		Go:       "*wrapperspb.Int64Value", Java: "com.google.protobuf.Int64Value", TS: "bigint"},
+	{Name: "google.protobuf.ListValue", Description: "List of dynamic values.",
+		JSON:     "array",
+		JSONType: JSONType{Type: "array"},
+		Go:       "*structpb.ListValue", Java: "com.google.protobuf.ListValue", TS: "ListValue"},
+	{Name: "google.protobuf.NullValue", Description: "Null value of a Value.",
+		JSON:     "null",
+		JSONType: JSONType{Type: "null"},
+		Go:       "structpb.NullValue", Java: "com.google.protobuf.NullValue", TS: "NullValue"},
+	{Name: "google.protobuf.StringValue", Description: "Wrapper for string, to tell unset from empty.",
+		JSON:     "string or null",
+		JSONType: JSONType{Type: "string", Nullable: true},
+		Go:       "*wrapperspb.StringValue", Java: "com.google.protobuf.StringValue", TS: "string"},
+	{Name: "google.protobuf.Struct", Description: "Dynamic object with string keys, like a JSON object.",
+		JSON:     "object",
+		JSONType: JSONType{Type: "object"},
+		Go:       "*structpb.Struct", Java: "com.google.protobuf.Struct", TS: "Struct"},
+	{Name: "google.protobuf.Timestamp", Description: "Point in time with nanosecond precision, independent of any time zone.",
+		JSON:     `RFC 3339 string in UTC, like "1972-01-01T10:00:20.021Z"`,
+		JSONType: JSONType{Type: "string", Format: "date-time"},
+		Go:       "*timestamppb.Timestamp", Java: "com.google.protobuf.Timestamp", TS: "Timestamp"},
+	{Name: "google.protobuf.UInt32Value", Description: "Wrapper for uint32, to tell unset from 0.",
+		JSON:     "number or null",
+		JSONType: JSONType{Type: "integer", Format: "uint32", Nullable: true},
+		Go:       "*wrapperspb.UInt32Value", Java: "com.google.protobuf.UInt32Value", TS: "number"},
+	{Name: "google.protobuf.UInt64Value", Description: "Wrapper for uint64, to tell unset from 0.",
+		JSON:     "string or null",
+		JSONType: JSONType{Type: "string", Format: "uint64", Nullable: true},
+		Go:       "*wrapperspb.UInt64Value", Java: "com.google.protobuf.UInt64Value", TS: "bigint"},
+	{Name: "google.protobuf.Value", Description: "Dynamic value: null, number, string, bool, Struct or ListValue.",
+		JSON:     "any JSON value",
+		JSONType: JSONType{}, // any value
+		Go:       "*structpb.Value", Java: "com.google.protobuf.Value", TS: "Value"},
+}
+
+// Get the short name of the type, like "Timestamp"
+func (t *WellKnownType) ShortName() string {
+	return t.Name[strings.LastIndex(t.Name, ".")+1:]
+}
+
+// Get the reference documentation URL of the type
+func (t *WellKnownType) DocURL() string {
+	if t.URL != "" {
+		return t.URL
+	}
+	return wellKnownTypesURL + "#" + strings.ToLower(t.ShortName())
+}
+
+// Find a well-known type by its full name, or nil if not found
+func FindWellKnownType(fullName string) *WellKnownType {
+	fullName = strings.TrimPrefix(fullName, ".")
+	for _, t := range WellKnownTypes {
+		if t.Name == fullName {
+			return t
+		}
+	}
+	return nil
+}